@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -14,8 +15,10 @@ import (
 	"github.com/chrollo-lucifer-12/shared/db"
 	"github.com/chrollo-lucifer-12/shared/env"
 	"github.com/chrollo-lucifer-12/shared/queue"
+	"github.com/chrollo-lucifer-12/shared/traffic"
 	"github.com/chrollo-lucifer-12/shared/utils"
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -68,42 +71,65 @@ func verifyDeployment(path string, r *http.Request, db *db.DB) (*db.Project, *db
 	return &project, &deployment, nil
 }
 
-func (h *ServerClient) queueDeployment(ctx context.Context, project *db.Project, userEnv string) (uuid.UUID, error) {
+func previewURL(subdomain string, sequence int) string {
+	baseDomain := env.BaseDomain.GetValue()
+	if baseDomain == "" || sequence == 0 {
+		return ""
+	}
+	return "https://" + utils.GetPreviewSubdomain(subdomain, sequence) + "." + baseDomain
+}
+
+func (h *ServerClient) queueDeployment(ctx context.Context, project *db.Project, target string) (*db.Deployment, error) {
 	d, err := gorm.G[db.Deployment](h.db.Raw()).
 		Where("project_id = ?", project.ID).
 		Where("status IN ?", []string{"QUEUED", "PENDING"}).
 		Find(ctx)
 	if err != nil {
-		return uuid.Nil, err
+		return nil, err
 	}
 	if len(d) > 0 {
-		return uuid.Nil, gorm.ErrInvalidData
+		return nil, gorm.ErrInvalidData
+	}
+
+	vars, err := h.db.GetEnvVarsForTarget(ctx, project.ID, target)
+	if err != nil {
+		return nil, err
+	}
+
+	envSnapshot := make(map[string]string, len(vars))
+	for _, v := range vars {
+		envSnapshot[v.Key] = v.Value
 	}
 
 	dep := &db.Deployment{
-		ProjectID: project.ID,
-		Status:    "QUEUED",
+		ProjectID:     project.ID,
+		Status:        "QUEUED",
+		Target:        target,
+		Env:           datatypes.NewJSONType(envSnapshot),
+		Framework:     project.Framework,
+		BuildSettings: project.BuildSettings,
+	}
+
+	buildSettings, err := json.Marshal(project.BuildSettings.Data())
+	if err != nil {
+		return nil, err
 	}
 
 	err = h.db.CreateDeployment(ctx, dep)
 	if err != nil {
-		return uuid.Nil, err
+		return nil, err
 	}
 
 	h.queue.NewWorkflowTask(queue.WorkflowJob{
-		GithubToken:  env.GithubToken.GetValue(),
-		Owner:        "chrollo-lucifer-12",
-		Repo:         "vercel",
-		Workflow:     "build.yml",
-		Ref:          "main",
-		GitURL:       project.GitUrl,
-		BucketID:     "builds",
-		ProjectSlug:  project.SubDomain + strconv.Itoa(dep.Sequence),
-		DeploymentID: dep.ID.String(),
-		UserEnv:      userEnv,
+		GitURL:        project.GitUrl,
+		BucketID:      "builds",
+		ProjectSlug:   utils.GetDeploymentPrefix(project.SubDomain, dep.Sequence),
+		DeploymentID:  dep.ID.String(),
+		Framework:     project.Framework,
+		BuildSettings: string(buildSettings),
 	})
 
-	return dep.ID, nil
+	return dep, nil
 }
 
 func (h *ServerClient) deployHandler(w http.ResponseWriter, r *http.Request) {
@@ -119,7 +145,17 @@ func (h *ServerClient) deployHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	depID, err := h.queueDeployment(ctx, &project, req.UserEnv)
+
+	target := req.Target
+	if target == "" {
+		target = db.TargetProduction
+	}
+	if !db.ValidTarget(target) {
+		http.Error(w, "invalid target, expected production or preview", http.StatusBadRequest)
+		return
+	}
+
+	dep, err := h.queueDeployment(ctx, &project, target)
 	if err != nil {
 		if err == gorm.ErrInvalidData {
 			http.Error(w, "another deployment is running", http.StatusConflict)
@@ -134,7 +170,7 @@ func (h *ServerClient) deployHandler(w http.ResponseWriter, r *http.Request) {
 	h.redis.Del(ctx, cacheKey)
 	h.redis.Del(ctx, cacheKeyProject)
 
-	response := dto.ToCreateDeploymentResponse("queued", project.SubDomain, depID.String())
+	response := dto.ToCreateDeploymentResponse("queued", project.SubDomain, dep.ID.String(), previewURL(project.SubDomain, dep.Sequence))
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
@@ -174,7 +210,7 @@ func (h *ServerClient) getAllDeploymentsHandler(w http.ResponseWriter, r *http.R
 
 	var d []dto.GetDeploymentResponse
 	for _, deployment := range deployments {
-		d = append(d, dto.ToGetDeploymentResponse(deployment))
+		d = append(d, dto.ToGetDeploymentResponse(deployment, previewURL(projectRes.SubDomain, deployment.Sequence)))
 	}
 
 	jsonData, _ := json.Marshal(d)
@@ -213,7 +249,12 @@ func (h *ServerClient) getDeploymentHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	response := dto.ToGetDeploymentWithLogsResponse(deploymentRes)
+	var preview string
+	if project, err := h.db.GetProjectByID(ctx, deploymentRes.ProjectID); err == nil {
+		preview = previewURL(project.SubDomain, deploymentRes.Sequence)
+	}
+
+	response := dto.ToGetDeploymentWithLogsResponse(deploymentRes, preview)
 
 	jsonData, _ := json.Marshal(response)
 	h.redis.Set(ctx, cacheKey, jsonData, 30*time.Minute)
@@ -301,3 +342,95 @@ func (h *ServerClient) getLiveLogs(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+func (h *ServerClient) activateDeployment(ctx context.Context, project *db.Project, deployment *db.Deployment) error {
+	if err := h.db.SetActiveDeployment(ctx, project.ID, deployment.ID); err != nil {
+		return err
+	}
+
+	project.ActiveDeploymentID = &deployment.ID
+
+	if len(project.TrafficSplit.Data().Weights) > 0 {
+		project.TrafficSplit = datatypes.NewJSONType(traffic.Split{})
+		if err := h.db.UpdateProjectColumns(ctx, project.ID, *project, "traffic_split"); err != nil {
+			return err
+		}
+	}
+
+	h.redis.Del(ctx, utils.GetSiteCacheKey(project.SubDomain))
+	h.redis.Del(ctx, fmt.Sprintf("project:slug:%s", project.SubDomain))
+	h.redis.Del(ctx, "deployments:project:"+project.SubDomain)
+
+	return nil
+}
+
+func (h *ServerClient) promoteDeploymentHandler(w http.ResponseWriter, r *http.Request) {
+	path := fmt.Sprintf("project/%s/%s", r.PathValue("id"), r.PathValue("deploymentID"))
+
+	project, deployment, err := verifyDeployment(path, r, h.db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if deployment == nil {
+		http.Error(w, "deployment id required", http.StatusBadRequest)
+		return
+	}
+
+	if deployment.Status != "SUCCESS" {
+		http.Error(w, "only successful deployments can be promoted", http.StatusConflict)
+		return
+	}
+
+	if deployment.Target == db.TargetPreview {
+		http.Error(w, "preview deployments cannot be promoted", http.StatusConflict)
+		return
+	}
+
+	ctx := r.Context()
+
+	if err := h.activateDeployment(ctx, project, deployment); err != nil {
+		http.Error(w, "failed to promote deployment: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.ToGetDeploymentResponse(*deployment, previewURL(project.SubDomain, deployment.Sequence)))
+}
+
+func (h *ServerClient) rollbackDeploymentHandler(w http.ResponseWriter, r *http.Request) {
+	path := fmt.Sprintf("project/%s", r.PathValue("id"))
+
+	project, _, err := verifyDeployment(path, r, h.db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	active, err := h.db.GetActiveDeployment(ctx, *project)
+	if err != nil {
+		http.Error(w, "project has no active deployment", http.StatusConflict)
+		return
+	}
+
+	previous, err := h.db.GetPreviousSuccessfulDeployment(ctx, project.ID, active.Sequence)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "no previous successful deployment", http.StatusConflict)
+			return
+		}
+		http.Error(w, "failed to find previous deployment: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.activateDeployment(ctx, project, &previous); err != nil {
+		http.Error(w, "failed to roll back deployment: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.ToGetDeploymentResponse(previous, previewURL(project.SubDomain, previous.Sequence)))
+}
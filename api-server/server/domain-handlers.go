@@ -0,0 +1,162 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/chrollo-lucifer-12/api-server/server/dto"
+	"github.com/chrollo-lucifer-12/shared/db"
+	"github.com/chrollo-lucifer-12/shared/domains"
+	"github.com/chrollo-lucifer-12/shared/utils"
+	"github.com/google/uuid"
+)
+
+func (h *ServerClient) verifyDomain(r *http.Request) (*db.Project, *db.Domain, error) {
+	domainID, err := uuid.Parse(r.PathValue("domainID"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid domain id")
+	}
+
+	project, _, err := verifyDeployment("project/"+r.PathValue("id"), r, h.db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	domain, err := h.db.GetDomainByID(r.Context(), domainID)
+	if err != nil || domain.ProjectID != project.ID {
+		return nil, nil, fmt.Errorf("domain not found")
+	}
+
+	return project, &domain, nil
+}
+
+func (h *ServerClient) addDomainHandler(w http.ResponseWriter, r *http.Request) {
+	project, _, err := verifyDeployment("project/"+r.PathValue("id"), r, h.db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req DomainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	name, err := domains.Normalize(req.Domain)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if base := h.domains.BaseDomain(); base != "" && (name == base || strings.HasSuffix(name, "."+base)) {
+		http.Error(w, "domains under "+base+" cannot be added", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	existing, err := h.db.GetDomainsByName(ctx, name)
+	if err != nil {
+		http.Error(w, "failed to add domain: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, domain := range existing {
+		if domain.ProjectID == project.ID || domain.Verified {
+			http.Error(w, "domain is already in use", http.StatusConflict)
+			return
+		}
+	}
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		http.Error(w, "failed to generate verification token", http.StatusInternalServerError)
+		return
+	}
+
+	domain := db.Domain{
+		ProjectID:         project.ID,
+		Name:              name,
+		VerificationToken: token,
+	}
+
+	if err := h.db.CreateDomain(ctx, &domain); err != nil {
+		http.Error(w, "failed to add domain: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(dto.ToDomainResponse(domain, h.domains.Target(project.SubDomain)))
+}
+
+func (h *ServerClient) listDomainsHandler(w http.ResponseWriter, r *http.Request) {
+	project, _, err := verifyDeployment("project/"+r.PathValue("id"), r, h.db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.db.GetProjectDomains(r.Context(), project.ID)
+	if err != nil {
+		http.Error(w, "failed to get domains: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := []dto.DomainResponse{}
+	for _, domain := range list {
+		response = append(response, dto.ToDomainResponse(domain, h.domains.Target(project.SubDomain)))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ServerClient) verifyDomainHandler(w http.ResponseWriter, r *http.Request) {
+	project, domain, err := h.verifyDomain(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	if _, err := h.domains.Check(ctx, h.db, *domain); err != nil {
+		log.Printf("domain %s not verified: %v", domain.Name, err)
+	}
+
+	updated, err := h.db.GetDomainByID(ctx, domain.ID)
+	if err != nil {
+		http.Error(w, "failed to get domain: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if updated.Verified != domain.Verified {
+		h.redis.Del(ctx, utils.GetDomainCacheKey(updated.Name))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.ToDomainResponse(updated, h.domains.Target(project.SubDomain)))
+}
+
+func (h *ServerClient) removeDomainHandler(w http.ResponseWriter, r *http.Request) {
+	_, domain, err := h.verifyDomain(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	if err := h.db.DeleteDomain(ctx, domain.ID); err != nil {
+		http.Error(w, "failed to remove domain", http.StatusInternalServerError)
+		return
+	}
+
+	h.redis.Del(ctx, utils.GetDomainCacheKey(domain.Name))
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -3,7 +3,11 @@ package dto
 import (
 	"time"
 
+	"github.com/chrollo-lucifer-12/shared/caching"
 	"github.com/chrollo-lucifer-12/shared/db"
+	"github.com/chrollo-lucifer-12/shared/domains"
+	"github.com/chrollo-lucifer-12/shared/framework"
+	"github.com/chrollo-lucifer-12/shared/security"
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
 )
@@ -34,13 +38,18 @@ type CreateDeploymentResponse struct {
 	Status       string `json:"status"`
 	ProjectSlug  string `json:"project_slug"`
 	DeploymentID string `json:"deployment_id"`
+	PreviewURL   string `json:"preview_url,omitempty"`
 }
 
 type GetDeploymentResponse struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	Status    string    `json:"status"`
-	Sequence  int       `json:"sequence"`
+	ID             uuid.UUID          `json:"id"`
+	CreatedAt      time.Time          `json:"created_at"`
+	Status         string             `json:"status"`
+	Sequence       int                `json:"sequence"`
+	PreviewURL     string             `json:"preview_url,omitempty"`
+	PackageManager string             `json:"package_manager,omitempty"`
+	Framework      string             `json:"framework,omitempty"`
+	BuildSettings  framework.Settings `json:"build_settings"`
 }
 
 type LogsResponse struct {
@@ -55,11 +64,13 @@ type GetDeploymentWithLogsResponse struct {
 }
 
 type CreateProjectResposne struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	SubDomain string    `json:"sub_domain"`
-	CreatedAt time.Time `json:"created_at"`
-	GitUrl    string    `json:"git_url"`
+	ID                 string     `json:"id"`
+	Name               string     `json:"name"`
+	SubDomain          string     `json:"sub_domain"`
+	CreatedAt          time.Time  `json:"created_at"`
+	GitUrl             string     `json:"git_url"`
+	ActiveDeploymentID *uuid.UUID `json:"active_deployment_id"`
+	ServingMode        string     `json:"serving_mode"`
 }
 
 type GetProjectWithDeployment struct {
@@ -67,20 +78,22 @@ type GetProjectWithDeployment struct {
 	Deployment GetDeploymentWithLogsResponse
 }
 
-func ToGetProjectWithDeployment(project db.Project, deployment db.Deployment) GetProjectWithDeployment {
+func ToGetProjectWithDeployment(project db.Project, deployment db.Deployment, previewURL string) GetProjectWithDeployment {
 	return GetProjectWithDeployment{
 		Project:    ToCreateProjectResposne(project),
-		Deployment: ToGetDeploymentWithLogsResponse(deployment),
+		Deployment: ToGetDeploymentWithLogsResponse(deployment, previewURL),
 	}
 }
 
 func ToCreateProjectResposne(project db.Project) CreateProjectResposne {
 	return CreateProjectResposne{
-		ID:        project.ID.String(),
-		Name:      project.Name,
-		SubDomain: project.SubDomain,
-		CreatedAt: project.CreatedAt,
-		GitUrl:    project.GitUrl,
+		ID:                 project.ID.String(),
+		Name:               project.Name,
+		SubDomain:          project.SubDomain,
+		CreatedAt:          project.CreatedAt,
+		GitUrl:             project.GitUrl,
+		ActiveDeploymentID: project.ActiveDeploymentID,
+		ServingMode:        project.ServingMode,
 	}
 }
 
@@ -94,27 +107,32 @@ func ToLogsResponse(logs []db.LogEvent) []LogsResponse {
 	return r
 }
 
-func ToGetDeploymentWithLogsResponse(deployment db.Deployment) GetDeploymentWithLogsResponse {
+func ToGetDeploymentWithLogsResponse(deployment db.Deployment, previewURL string) GetDeploymentWithLogsResponse {
 	return GetDeploymentWithLogsResponse{
-		Deployment: ToGetDeploymentResponse(deployment),
+		Deployment: ToGetDeploymentResponse(deployment, previewURL),
 		Logs:       ToLogsResponse(deployment.LogEvents),
 	}
 }
 
-func ToGetDeploymentResponse(deployment db.Deployment) GetDeploymentResponse {
+func ToGetDeploymentResponse(deployment db.Deployment, previewURL string) GetDeploymentResponse {
 	return GetDeploymentResponse{
-		ID:        deployment.ID,
-		CreatedAt: deployment.CreatedAt,
-		Status:    deployment.Status,
-		Sequence:  deployment.Sequence,
+		ID:             deployment.ID,
+		CreatedAt:      deployment.CreatedAt,
+		Status:         deployment.Status,
+		Sequence:       deployment.Sequence,
+		PreviewURL:     previewURL,
+		PackageManager: deployment.PackageManager,
+		Framework:      deployment.Framework,
+		BuildSettings:  deployment.BuildSettings.Data(),
 	}
 }
 
-func ToCreateDeploymentResponse(status, projectSlug, deploymentID string) CreateDeploymentResponse {
+func ToCreateDeploymentResponse(status, projectSlug, deploymentID, previewURL string) CreateDeploymentResponse {
 	return CreateDeploymentResponse{
 		Status:       status,
 		ProjectSlug:  projectSlug,
 		DeploymentID: deploymentID,
+		PreviewURL:   previewURL,
 	}
 }
 
@@ -145,3 +163,145 @@ func ToLoginResponse(user db.User, sessionID string, refreshToken string, access
 		User:                  ToUserResponse(user),
 	}
 }
+
+type ProtectionResponse struct {
+	Mode           string `json:"mode"`
+	Username       string `json:"username,omitempty"`
+	PasswordSet    bool   `json:"password_set"`
+	BypassTokenSet bool   `json:"bypass_token_set"`
+	BypassToken    string `json:"bypass_token,omitempty"`
+}
+
+func ToProtectionResponse(protection security.Protection, bypassToken string) ProtectionResponse {
+	mode := protection.Mode
+	if mode == "" {
+		mode = security.ProtectionNone
+	}
+
+	return ProtectionResponse{
+		Mode:           mode,
+		Username:       protection.Username,
+		PasswordSet:    protection.PasswordHash != "",
+		BypassTokenSet: protection.BypassTokenHash != "",
+		BypassToken:    bypassToken,
+	}
+}
+
+type ProjectSettingsResponse struct {
+	ServingMode  string                `json:"serving_mode"`
+	HeaderPolicy security.HeaderPolicy `json:"header_policy"`
+	Protection   ProtectionResponse    `json:"protection"`
+	Firewall     security.Firewall     `json:"firewall"`
+	CachePolicy  caching.Policy        `json:"cache_policy"`
+	Framework    string                `json:"framework"`
+	Build        framework.Settings    `json:"build"`
+}
+
+func ToProjectSettingsResponse(project db.Project, bypassToken string) ProjectSettingsResponse {
+	return ProjectSettingsResponse{
+		ServingMode:  project.ServingMode,
+		HeaderPolicy: project.HeaderPolicy.Data(),
+		Protection:   ToProtectionResponse(project.Protection.Data(), bypassToken),
+		Firewall:     project.Firewall.Data(),
+		CachePolicy:  project.CachePolicy.Data(),
+		Framework:    project.Framework,
+		Build:        project.BuildSettings.Data(),
+	}
+}
+
+type TrafficVariant struct {
+	DeploymentID uuid.UUID `json:"deployment_id"`
+	Weight       int       `json:"weight"`
+	Active       bool      `json:"active"`
+}
+
+type TrafficResponse struct {
+	ActiveDeploymentID *uuid.UUID       `json:"active_deployment_id"`
+	Canary             bool             `json:"canary"`
+	Variants           []TrafficVariant `json:"variants"`
+}
+
+func ToTrafficResponse(project db.Project) TrafficResponse {
+	split := project.TrafficSplit.Data()
+	canaries := split.Canaries()
+
+	variants := make([]TrafficVariant, 0, len(canaries)+1)
+	if project.ActiveDeploymentID != nil {
+		variants = append(variants, TrafficVariant{
+			DeploymentID: *project.ActiveDeploymentID,
+			Weight:       100 - split.Total(),
+			Active:       true,
+		})
+	}
+	for _, canary := range canaries {
+		variants = append(variants, TrafficVariant{
+			DeploymentID: canary.DeploymentID,
+			Weight:       canary.Weight,
+		})
+	}
+
+	return TrafficResponse{
+		ActiveDeploymentID: project.ActiveDeploymentID,
+		Canary:             len(canaries) > 0,
+		Variants:           variants,
+	}
+}
+
+type EnvVarResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Key       string    `json:"key"`
+	Target    string    `json:"target"`
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func ToEnvVarResponse(v db.EnvVar) EnvVarResponse {
+	return EnvVarResponse{
+		ID:        v.ID,
+		Key:       v.Key,
+		Target:    v.Target,
+		Value:     v.Hint,
+		CreatedAt: v.CreatedAt,
+		UpdatedAt: v.UpdatedAt,
+	}
+}
+
+type DNSRecord struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type DomainResponse struct {
+	ID            uuid.UUID   `json:"id"`
+	Name          string      `json:"name"`
+	Verified      bool        `json:"verified"`
+	VerifiedAt    *time.Time  `json:"verified_at"`
+	LastCheckedAt *time.Time  `json:"last_checked_at"`
+	LastError     string      `json:"last_error,omitempty"`
+	Expired       bool        `json:"expired,omitempty"`
+	Records       []DNSRecord `json:"records"`
+}
+
+func ToDomainResponse(domain db.Domain, target string) DomainResponse {
+	records := []DNSRecord{{
+		Type:  "TXT",
+		Name:  domains.ChallengeName(domain.Name),
+		Value: domains.ChallengeValue(domain.VerificationToken),
+	}}
+	if target != "" {
+		records = append(records, DNSRecord{Type: "CNAME", Name: domain.Name, Value: target})
+	}
+
+	return DomainResponse{
+		ID:            domain.ID,
+		Name:          domain.Name,
+		Verified:      domain.Verified,
+		VerifiedAt:    domain.VerifiedAt,
+		LastCheckedAt: domain.LastCheckedAt,
+		LastError:     domain.LastError,
+		Expired:       domains.Expired(domain, time.Now()),
+		Records:       records,
+	}
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/chrollo-lucifer-12/api-server/server/dto"
+	"github.com/chrollo-lucifer-12/shared/db"
+	"github.com/chrollo-lucifer-12/shared/env"
+	"github.com/chrollo-lucifer-12/shared/secrets"
+	"github.com/google/uuid"
+)
+
+const maxEnvValueBytes = 64 << 10
+
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,255}$`)
+
+func (h *ServerClient) verifyEnvVar(r *http.Request) (*db.Project, *db.EnvVar, error) {
+	envID, err := uuid.Parse(r.PathValue("envID"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid environment variable id")
+	}
+
+	project, _, err := verifyDeployment("project/"+r.PathValue("id"), r, h.db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v, err := h.db.GetEnvVar(r.Context(), envID)
+	if err != nil || v.ProjectID != project.ID {
+		return nil, nil, fmt.Errorf("environment variable not found")
+	}
+
+	return project, &v, nil
+}
+
+func (h *ServerClient) sealEnvValue(projectID uuid.UUID, key, value string) (string, error) {
+	if len(value) > maxEnvValueBytes {
+		return "", fmt.Errorf("value is larger than %d bytes", maxEnvValueBytes)
+	}
+	return h.secrets.Encrypt(value, secrets.AdditionalData(projectID.String(), key))
+}
+
+func (h *ServerClient) listEnvVarsHandler(w http.ResponseWriter, r *http.Request) {
+	project, _, err := verifyDeployment("project/"+r.PathValue("id"), r, h.db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	vars, err := h.db.GetEnvVars(r.Context(), project.ID)
+	if err != nil {
+		http.Error(w, "failed to get environment variables: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := []dto.EnvVarResponse{}
+	for _, v := range vars {
+		response = append(response, dto.ToEnvVarResponse(v))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *ServerClient) createEnvVarHandler(w http.ResponseWriter, r *http.Request) {
+	project, _, err := verifyDeployment("project/"+r.PathValue("id"), r, h.db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if h.secrets == nil {
+		http.Error(w, "environment variables are not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req EnvVarRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !envKeyPattern.MatchString(req.Key) {
+		http.Error(w, "invalid key, use letters, digits and underscores", http.StatusBadRequest)
+		return
+	}
+
+	if env.Reserved(req.Key) {
+		http.Error(w, req.Key+" is reserved and cannot be set", http.StatusBadRequest)
+		return
+	}
+
+	if req.Target == "" {
+		req.Target = db.TargetProduction
+	}
+	if !db.ValidTarget(req.Target) {
+		http.Error(w, "invalid target, expected production or preview", http.StatusBadRequest)
+		return
+	}
+
+	if req.Value == nil {
+		http.Error(w, "value is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	existing, err := h.db.GetEnvVarsForTarget(ctx, project.ID, req.Target)
+	if err != nil {
+		http.Error(w, "failed to create environment variable: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, v := range existing {
+		if v.Key == req.Key {
+			http.Error(w, "environment variable already exists for this target", http.StatusConflict)
+			return
+		}
+	}
+
+	sealed, err := h.sealEnvValue(project.ID, req.Key, *req.Value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := db.EnvVar{
+		ProjectID: project.ID,
+		Key:       req.Key,
+		Target:    req.Target,
+		Value:     sealed,
+		Hint:      secrets.Mask(*req.Value),
+	}
+
+	if err := h.db.CreateEnvVar(ctx, &v); err != nil {
+		http.Error(w, "failed to create environment variable: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(dto.ToEnvVarResponse(v))
+}
+
+func (h *ServerClient) updateEnvVarHandler(w http.ResponseWriter, r *http.Request) {
+	project, v, err := h.verifyEnvVar(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if h.secrets == nil {
+		http.Error(w, "environment variables are not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req EnvVarRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Value == nil {
+		http.Error(w, "value is required", http.StatusBadRequest)
+		return
+	}
+
+	if env.Reserved(v.Key) {
+		http.Error(w, v.Key+" is reserved and cannot be set", http.StatusBadRequest)
+		return
+	}
+
+	sealed, err := h.sealEnvValue(project.ID, v.Key, *req.Value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v.Value = sealed
+	v.Hint = secrets.Mask(*req.Value)
+
+	if err := h.db.UpdateEnvVar(r.Context(), v.ID, db.EnvVar{Value: v.Value, Hint: v.Hint}); err != nil {
+		http.Error(w, "failed to update environment variable: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.ToEnvVarResponse(*v))
+}
+
+func (h *ServerClient) deleteEnvVarHandler(w http.ResponseWriter, r *http.Request) {
+	_, v, err := h.verifyEnvVar(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.db.DeleteEnvVar(r.Context(), v.ID); err != nil {
+		http.Error(w, "failed to delete environment variable: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -13,8 +13,11 @@ import (
 
 	"github.com/chrollo-lucifer-12/api-server/auth"
 	"github.com/chrollo-lucifer-12/shared/db"
+	"github.com/chrollo-lucifer-12/shared/domains"
+	"github.com/chrollo-lucifer-12/shared/env"
 	"github.com/chrollo-lucifer-12/shared/queue"
 	"github.com/chrollo-lucifer-12/shared/redis"
+	"github.com/chrollo-lucifer-12/shared/secrets"
 )
 
 func NewServerClient(dbClient *db.DB, redisClient *redis.RedisClient, queueClient *queue.QueueClient) (*ServerClient, error) {
@@ -25,11 +28,21 @@ func NewServerClient(dbClient *db.DB, redisClient *redis.RedisClient, queueClien
 
 	authService := newAuthService(dbClient)
 
+	box, err := secrets.New(env.EnvEncryptionKey.GetValue())
+	if err != nil {
+		log.Printf("environment variables are disabled: %v", err)
+	}
+
 	server := &ServerClient{
 		db:    dbClient,
 		auth:  authService,
 		redis: redisClient,
 		queue: queueClient,
+		domains: domains.NewVerifier(
+			domains.NewResolver(env.DnsResolverAddr.GetValue()),
+			env.BaseDomain.GetValue(),
+		),
+		secrets: box,
 	}
 
 	server.setupHTTP()
@@ -79,13 +92,30 @@ func (s *ServerClient) registerRoutes(mux *http.ServeMux) {
 		{"/api/v1/deploy/create", http.MethodPost, s.deployHandler, true},
 		{"/api/v1/project/create", http.MethodPost, s.createProjectHandler, true},
 		{"/api/v1/projects", http.MethodGet, s.getAllProjectsHandler, true},
-		{"/api/v1/project/", http.MethodGet, s.getProjectHandler, true},
-		{"/api/v1/project/delete/", http.MethodDelete, s.deleteProjectHandler, true},
+		{"/api/v1/project/{id}", http.MethodGet, s.getProjectHandler, true},
+		{"/api/v1/project/{id}", http.MethodDelete, s.deleteProjectHandler, true},
 		{"/api/v1/auth/logout/{sessionID}", http.MethodDelete, s.logoutUserHandler, true},
 		{"/api/v1/deployments/", http.MethodGet, s.getAllDeploymentsHandler, true},
 		{"/api/v1/deployment/", http.MethodGet, s.getDeploymentHandler, true},
 		{"/api/v1/deployment/logs/", http.MethodGet, s.getLiveLogs, false},
-		{"/api/v1/project/analytics/", http.MethodGet, s.getProjectAnalytics, true},
+		{"/api/v1/project/{id}/analytics", http.MethodGet, s.getProjectAnalytics, true},
+		{"/api/v1/project/{id}/promote/{deploymentID}", http.MethodPost, s.promoteDeploymentHandler, true},
+		{"/api/v1/project/{id}/rollback", http.MethodPost, s.rollbackDeploymentHandler, true},
+		{"/api/v1/project/{id}/settings", http.MethodGet, s.getProjectSettingsHandler, true},
+		{"/api/v1/project/{id}/settings", http.MethodPatch, s.updateProjectSettingsHandler, true},
+		{"/api/v1/project/{id}/protection/{deploymentID}", http.MethodPut, s.updateDeploymentProtectionHandler, true},
+		{"/api/v1/project/{id}/traffic", http.MethodGet, s.getTrafficHandler, true},
+		{"/api/v1/project/{id}/traffic", http.MethodPut, s.updateTrafficHandler, true},
+		{"/api/v1/project/{id}/traffic/finish", http.MethodPost, s.finishTrafficHandler, true},
+		{"/api/v1/project/{id}/traffic/abort", http.MethodPost, s.abortTrafficHandler, true},
+		{"/api/v1/project/{id}/env", http.MethodGet, s.listEnvVarsHandler, true},
+		{"/api/v1/project/{id}/env", http.MethodPost, s.createEnvVarHandler, true},
+		{"/api/v1/project/{id}/env/{envID}", http.MethodPatch, s.updateEnvVarHandler, true},
+		{"/api/v1/project/{id}/env/{envID}", http.MethodDelete, s.deleteEnvVarHandler, true},
+		{"/api/v1/project/{id}/domains", http.MethodGet, s.listDomainsHandler, true},
+		{"/api/v1/project/{id}/domains", http.MethodPost, s.addDomainHandler, true},
+		{"/api/v1/project/{id}/domains/{domainID}", http.MethodDelete, s.removeDomainHandler, true},
+		{"/api/v1/project/{id}/domains/{domainID}/verify", http.MethodPost, s.verifyDomainHandler, true},
 
 		{"/api/v1/auth/register", http.MethodPost, s.registerUserHandler, false},
 		{"/auth/verify-email", http.MethodGet, s.verifyEmailHandler, false},
@@ -95,11 +125,13 @@ func (s *ServerClient) registerRoutes(mux *http.ServeMux) {
 		{"/api/v1/user/me", http.MethodGet, s.getUserProfileHandler, true},
 	}
 
+	methods := make(map[string]map[string]http.Handler)
+	var paths []string
+
 	for _, r := range routes {
 
 		handler := Chain(
 			http.HandlerFunc(r.handler),
-			s.methodMiddleware(r.method),
 			s.loggingMiddleware,
 		)
 
@@ -107,7 +139,15 @@ func (s *ServerClient) registerRoutes(mux *http.ServeMux) {
 			handler = Chain(handler, s.authMiddleware)
 		}
 
-		mux.Handle(r.path, handler)
+		if _, ok := methods[r.path]; !ok {
+			methods[r.path] = make(map[string]http.Handler)
+			paths = append(paths, r.path)
+		}
+		methods[r.path][r.method] = handler
+	}
+
+	for _, path := range paths {
+		mux.Handle(path, s.methodRouter(methods[path]))
 	}
 }
 
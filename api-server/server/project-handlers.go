@@ -7,7 +7,6 @@ import (
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/chrollo-lucifer-12/api-server/auth"
@@ -18,6 +17,14 @@ import (
 	"gorm.io/gorm"
 )
 
+func validServingMode(mode string) bool {
+	switch mode {
+	case db.ServingModeStatic, db.ServingModeSPA, db.ServingModeCleanURLs:
+		return true
+	}
+	return false
+}
+
 func (h *ServerClient) createProjectHandler(w http.ResponseWriter, r *http.Request) {
 	var req ProjectRequest
 
@@ -26,6 +33,15 @@ func (h *ServerClient) createProjectHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if req.ServingMode == "" {
+		req.ServingMode = db.ServingModeStatic
+	}
+
+	if !validServingMode(req.ServingMode) {
+		http.Error(w, "invalid serving mode", http.StatusBadRequest)
+		return
+	}
+
 	claims := r.Context().Value(authKey{}).(*auth.UserClaims)
 	userID := claims.ID
 
@@ -36,10 +52,11 @@ func (h *ServerClient) createProjectHandler(w http.ResponseWriter, r *http.Reque
 	}
 
 	project := db.Project{
-		Name:      req.ProjectName,
-		GitUrl:    req.GithubURL,
-		SubDomain: subdomain,
-		UserID:    userID,
+		Name:        req.ProjectName,
+		GitUrl:      req.GithubURL,
+		SubDomain:   subdomain,
+		UserID:      userID,
+		ServingMode: req.ServingMode,
 	}
 
 	ctx := r.Context()
@@ -123,7 +140,7 @@ func (h *ServerClient) getAllProjectsHandler(w http.ResponseWriter, r *http.Requ
 }
 
 func (h *ServerClient) getProjectHandler(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/api/v1/project/")
+	path := r.PathValue("id")
 	if path == "" {
 		http.Error(w, "project id required", http.StatusBadRequest)
 		return
@@ -152,7 +169,7 @@ func (h *ServerClient) getProjectHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	response := dto.ToGetProjectWithDeployment(projectRes, deployment)
+	response := dto.ToGetProjectWithDeployment(projectRes, deployment, previewURL(projectRes.SubDomain, deployment.Sequence))
 
 	jsonData, _ := json.Marshal(response)
 
@@ -165,7 +182,7 @@ func (h *ServerClient) getProjectHandler(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *ServerClient) deleteProjectHandler(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/api/v1/project/delete/")
+	path := r.PathValue("id")
 	if path == "" {
 		http.Error(w, "project id required", http.StatusBadRequest)
 		return
@@ -189,7 +206,7 @@ func (h *ServerClient) deleteProjectHandler(w http.ResponseWriter, r *http.Reque
 }
 
 func (h *ServerClient) getProjectAnalytics(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/api/v1/project/analytics/")
+	path := r.PathValue("id")
 	if path == "" {
 		http.Error(w, "project id required", http.StatusBadRequest)
 		return
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/chrollo-lucifer-12/api-server/server/dto"
+	"github.com/chrollo-lucifer-12/shared/db"
+	"github.com/chrollo-lucifer-12/shared/framework"
+	"github.com/chrollo-lucifer-12/shared/security"
+	"github.com/chrollo-lucifer-12/shared/utils"
+	"gorm.io/datatypes"
+)
+
+func (h *ServerClient) invalidateSite(ctx context.Context, project *db.Project) {
+	h.redis.Del(ctx, utils.GetSiteCacheKey(project.SubDomain))
+	h.redis.Del(ctx, fmt.Sprintf("project:slug:%s", project.SubDomain))
+
+	if err := h.redis.DeleteByPattern(ctx, utils.GetPreviewSiteCachePattern(project.SubDomain)); err != nil {
+		log.Println("cache invalidation error:", err)
+	}
+
+	if err := h.redis.DeleteByPattern(ctx, fmt.Sprintf("projects:user:%s:*", project.UserID)); err != nil {
+		log.Println("cache invalidation error:", err)
+	}
+
+	active, err := h.db.GetActiveDeployment(ctx, *project)
+	if err != nil {
+		return
+	}
+
+	prefix := utils.GetDeploymentPrefix(project.SubDomain, active.Sequence)
+	if err := h.redis.Publish(ctx, utils.CacheInvalidationChannel, prefix); err != nil {
+		log.Println("cache invalidation error:", err)
+	}
+}
+
+func buildProtection(current security.Protection, req ProtectionRequest) (security.Protection, string, error) {
+	switch req.Mode {
+	case security.ProtectionInherit:
+		return security.Protection{}, "", nil
+	case "", security.ProtectionNone:
+		return security.Protection{Mode: security.ProtectionNone}, "", nil
+	}
+
+	protection := current
+	protection.Mode = req.Mode
+	protection.Username = ""
+
+	if req.Mode == security.ProtectionBasic {
+		protection.Username = req.Username
+	}
+
+	if req.Password != "" {
+		if len(req.Password) < 8 {
+			return security.Protection{}, "", fmt.Errorf("password must be at least 8 characters")
+		}
+		hash, err := utils.HashPassword(req.Password)
+		if err != nil {
+			return security.Protection{}, "", err
+		}
+		protection.PasswordHash = hash
+	}
+
+	var token string
+	if req.GenerateBypassToken {
+		generated, err := utils.GenerateToken()
+		if err != nil {
+			return security.Protection{}, "", err
+		}
+		token = generated
+		protection.BypassTokenHash = security.HashToken(token)
+	}
+
+	if err := protection.Validate(); err != nil {
+		return security.Protection{}, "", err
+	}
+
+	return protection, token, nil
+}
+
+func (h *ServerClient) getProjectSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	project, _, err := verifyDeployment("project/"+r.PathValue("id"), r, h.db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.ToProjectSettingsResponse(*project, ""))
+}
+
+func (h *ServerClient) updateProjectSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	project, _, err := verifyDeployment("project/"+r.PathValue("id"), r, h.db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req ProjectSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var update db.Project
+	var columns []string
+
+	if req.ServingMode != nil {
+		if !validServingMode(*req.ServingMode) {
+			http.Error(w, "invalid serving mode", http.StatusBadRequest)
+			return
+		}
+		update.ServingMode = *req.ServingMode
+		project.ServingMode = *req.ServingMode
+		columns = append(columns, "serving_mode")
+	}
+
+	if req.HeaderPolicy != nil {
+		policy := *req.HeaderPolicy
+		if policy.Preset == "" {
+			policy.Preset = security.PresetDefault
+		}
+		if err := policy.Validate(); err != nil {
+			http.Error(w, "invalid header policy: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		update.HeaderPolicy = datatypes.NewJSONType(policy)
+		project.HeaderPolicy = update.HeaderPolicy
+		columns = append(columns, "header_policy")
+	}
+
+	var bypassToken string
+	if req.Protection != nil {
+		if req.Protection.Mode == security.ProtectionInherit {
+			http.Error(w, "invalid protection: projects cannot inherit protection", http.StatusBadRequest)
+			return
+		}
+
+		protection, token, err := buildProtection(project.Protection.Data(), *req.Protection)
+		if err != nil {
+			http.Error(w, "invalid protection: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		bypassToken = token
+		update.Protection = datatypes.NewJSONType(protection)
+		project.Protection = update.Protection
+		columns = append(columns, "protection")
+	}
+
+	if req.Firewall != nil {
+		firewall := *req.Firewall
+		if err := firewall.Normalize(); err != nil {
+			http.Error(w, "invalid firewall: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		update.Firewall = datatypes.NewJSONType(firewall)
+		project.Firewall = update.Firewall
+		columns = append(columns, "firewall")
+	}
+
+	if req.CachePolicy != nil {
+		if err := req.CachePolicy.Validate(); err != nil {
+			http.Error(w, "invalid cache policy: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		update.CachePolicy = datatypes.NewJSONType(*req.CachePolicy)
+		project.CachePolicy = update.CachePolicy
+		columns = append(columns, "cache_policy")
+	}
+
+	if req.Framework != nil {
+		if !framework.Valid(*req.Framework) {
+			http.Error(w, "invalid framework, expected one of "+strings.Join(framework.Names(), ", "), http.StatusBadRequest)
+			return
+		}
+		update.Framework = *req.Framework
+		project.Framework = *req.Framework
+		columns = append(columns, "framework")
+	}
+
+	if req.Build != nil {
+		settings := req.Build.Normalize()
+		if err := settings.Validate(); err != nil {
+			http.Error(w, "invalid build settings: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		update.BuildSettings = datatypes.NewJSONType(settings)
+		project.BuildSettings = update.BuildSettings
+		columns = append(columns, "build_settings")
+	}
+
+	if len(columns) == 0 {
+		http.Error(w, "no settings to update", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	if err := h.db.UpdateProjectColumns(ctx, project.ID, update, columns...); err != nil {
+		http.Error(w, "failed to update settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.invalidateSite(ctx, project)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.ToProjectSettingsResponse(*project, bypassToken))
+}
+
+func (h *ServerClient) updateDeploymentProtectionHandler(w http.ResponseWriter, r *http.Request) {
+	path := fmt.Sprintf("project/%s/%s", r.PathValue("id"), r.PathValue("deploymentID"))
+
+	project, deployment, err := verifyDeployment(path, r, h.db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if deployment == nil {
+		http.Error(w, "deployment id required", http.StatusBadRequest)
+		return
+	}
+
+	var req ProtectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	protection, bypassToken, err := buildProtection(deployment.Protection.Data(), req)
+	if err != nil {
+		http.Error(w, "invalid protection: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	if err := h.db.SetDeploymentProtection(ctx, deployment.ID, protection); err != nil {
+		http.Error(w, "failed to update protection: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.invalidateSite(ctx, project)
+
+	response := dto.ToProtectionResponse(protection, bypassToken)
+	if protection.Mode == "" {
+		response.Mode = security.ProtectionInherit
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/chrollo-lucifer-12/api-server/server/dto"
+	"github.com/chrollo-lucifer-12/shared/db"
+	"github.com/chrollo-lucifer-12/shared/traffic"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+func (h *ServerClient) saveTrafficSplit(ctx context.Context, project *db.Project, split traffic.Split) error {
+	project.TrafficSplit = datatypes.NewJSONType(split)
+	if err := h.db.UpdateProjectColumns(ctx, project.ID, *project, "traffic_split"); err != nil {
+		return err
+	}
+
+	h.invalidateSite(ctx, project)
+	return nil
+}
+
+func (h *ServerClient) getTrafficHandler(w http.ResponseWriter, r *http.Request) {
+	project, _, err := verifyDeployment("project/"+r.PathValue("id"), r, h.db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.ToTrafficResponse(*project))
+}
+
+func (h *ServerClient) updateTrafficHandler(w http.ResponseWriter, r *http.Request) {
+	project, _, err := verifyDeployment("project/"+r.PathValue("id"), r, h.db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req TrafficRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	split := traffic.Split{Weights: req.Weights}
+	if err := split.Validate(); err != nil {
+		http.Error(w, "invalid traffic split: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if project.ActiveDeploymentID == nil {
+		http.Error(w, "project has no active deployment", http.StatusConflict)
+		return
+	}
+
+	ctx := r.Context()
+
+	ids := make([]uuid.UUID, 0, len(split.Weights))
+	for id := range split.Weights {
+		if id == *project.ActiveDeploymentID {
+			http.Error(w, "the active deployment receives the remaining traffic and cannot be a canary", http.StatusBadRequest)
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	if len(ids) > 0 {
+		deployments, err := h.db.GetSuccessfulDeployments(ctx, project.ID, ids)
+		if err != nil {
+			http.Error(w, "failed to load deployments: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if len(deployments) != len(ids) {
+			http.Error(w, "canaries must be successful production deployments of this project", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.saveTrafficSplit(ctx, project, split); err != nil {
+		http.Error(w, "failed to update traffic split: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.ToTrafficResponse(*project))
+}
+
+func (h *ServerClient) finishTrafficHandler(w http.ResponseWriter, r *http.Request) {
+	var req FinishTrafficRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	path := fmt.Sprintf("project/%s/%s", r.PathValue("id"), req.DeploymentID)
+
+	project, deployment, err := verifyDeployment(path, r, h.db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := project.TrafficSplit.Data().Weights[deployment.ID]; !ok {
+		http.Error(w, "deployment is not part of the traffic split", http.StatusConflict)
+		return
+	}
+
+	if deployment.Status != "SUCCESS" {
+		http.Error(w, "only successful deployments can be promoted", http.StatusConflict)
+		return
+	}
+
+	if deployment.Target == db.TargetPreview {
+		http.Error(w, "preview deployments cannot be promoted", http.StatusConflict)
+		return
+	}
+
+	ctx := r.Context()
+
+	if err := h.activateDeployment(ctx, project, deployment); err != nil {
+		http.Error(w, "failed to promote deployment: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.ToTrafficResponse(*project))
+}
+
+func (h *ServerClient) abortTrafficHandler(w http.ResponseWriter, r *http.Request) {
+	project, _, err := verifyDeployment("project/"+r.PathValue("id"), r, h.db)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.saveTrafficSplit(r.Context(), project, traffic.Split{}); err != nil {
+		http.Error(w, "failed to clear traffic split: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.ToTrafficResponse(*project))
+}
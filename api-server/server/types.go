@@ -5,21 +5,62 @@ import (
 	"time"
 
 	"github.com/chrollo-lucifer-12/api-server/auth"
+	"github.com/chrollo-lucifer-12/shared/caching"
 	"github.com/chrollo-lucifer-12/shared/db"
+	"github.com/chrollo-lucifer-12/shared/domains"
+	"github.com/chrollo-lucifer-12/shared/framework"
 	"github.com/chrollo-lucifer-12/shared/queue"
 	"github.com/chrollo-lucifer-12/shared/redis"
+	"github.com/chrollo-lucifer-12/shared/secrets"
+	"github.com/chrollo-lucifer-12/shared/security"
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
 )
 
 type DeployRequest struct {
-	UserEnv     string `json:"user_env"`
 	ProjectSlug string `json:"project_slug"`
+	Target      string `json:"target"`
 }
 
 type ProjectRequest struct {
 	ProjectName string `json:"project_name"`
 	GithubURL   string `json:"github_url"`
+	ServingMode string `json:"serving_mode"`
+}
+
+type ProtectionRequest struct {
+	Mode                string `json:"mode"`
+	Username            string `json:"username"`
+	Password            string `json:"password"`
+	GenerateBypassToken bool   `json:"generate_bypass_token"`
+}
+
+type ProjectSettingsRequest struct {
+	ServingMode  *string                `json:"serving_mode"`
+	HeaderPolicy *security.HeaderPolicy `json:"header_policy"`
+	Protection   *ProtectionRequest     `json:"protection"`
+	Firewall     *security.Firewall     `json:"firewall"`
+	CachePolicy  *caching.Policy        `json:"cache_policy"`
+	Framework    *string                `json:"framework"`
+	Build        *framework.Settings    `json:"build"`
+}
+
+type TrafficRequest struct {
+	Weights map[uuid.UUID]int `json:"weights"`
+}
+
+type FinishTrafficRequest struct {
+	DeploymentID uuid.UUID `json:"deployment_id"`
+}
+
+type EnvVarRequest struct {
+	Key    string  `json:"key"`
+	Value  *string `json:"value"`
+	Target string  `json:"target"`
+}
+
+type DomainRequest struct {
+	Domain string `json:"domain"`
 }
 
 type LogRequest struct {
@@ -41,11 +82,13 @@ type authKey struct{}
 type Middleware func(http.Handler) http.Handler
 
 type ServerClient struct {
-	db     *db.DB
-	auth   *auth.AuthService
-	server *http.Server
-	redis  *redis.RedisClient
-	queue  *queue.QueueClient
+	db      *db.DB
+	auth    *auth.AuthService
+	server  *http.Server
+	redis   *redis.RedisClient
+	queue   *queue.QueueClient
+	domains *domains.Verifier
+	secrets *secrets.Box
 }
 
 type route struct {
@@ -2,24 +2,82 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os/exec"
-	"runtime"
+	"strings"
+
+	"github.com/chrollo-lucifer-12/shared/framework"
 )
 
-func RunNpmCommand(
+func runBuild(ctx context.Context, dir string, fw framework.Framework, pm PackageManager, logger func(string)) error {
+	if len(fw.InstallCommand) > 0 {
+		logger("Running " + strings.Join(fw.InstallCommand, " ") + "...")
+		if err := RunCommand(ctx, dir, logger, fw.InstallCommand[0], fw.InstallCommand[1:]...); err != nil {
+			return fmt.Errorf("install command failed: %w", err)
+		}
+	} else if fw.Install {
+		if err := pm.Install(ctx, dir, logger); err != nil {
+			return fmt.Errorf("%s install failed: %w", pm.Name, err)
+		}
+	}
+
+	if fw.Script != "" {
+		if err := pm.Run(ctx, dir, logger, fw.Script); err != nil {
+			return fmt.Errorf("%s run %s failed: %w", pm.Name, fw.Script, err)
+		}
+	} else if fw.Install && len(fw.Command) == 0 {
+		logger("package.json has no build script, skipping build")
+	}
+
+	if len(fw.Command) > 0 {
+		logger("Running " + strings.Join(fw.Command, " ") + "...")
+		if err := RunCommand(ctx, dir, logger, fw.Command[0], fw.Command[1:]...); err != nil {
+			return fmt.Errorf("%s failed: %w", fw.Command[0], err)
+		}
+	}
+
+	return nil
+}
+
+func setupNode(ctx context.Context, dir string, version string, logger func(string)) error {
+	if version != "" {
+		logger("Installing Node.js " + version + "...")
+		if err := runCommand(ctx, dir, logger, nil, "n", "install", version); err != nil {
+			return err
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "node", "--version")
+	builder.apply(cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		return err
+	}
+	logger("Using Node.js " + strings.TrimSpace(string(out)))
+	return nil
+}
+
+func RunCommand(
 	ctx context.Context,
 	dir string,
-	streamName string,
 	logger func(string),
+	name string,
 	args ...string,
 ) error {
-	npm := "npm"
-	if runtime.GOOS == "windows" {
-		npm = "npm.cmd"
-	}
+	return runCommand(ctx, dir, logger, builder, name, args...)
+}
 
-	cmd := exec.CommandContext(ctx, npm, args...)
+func runCommand(
+	ctx context.Context,
+	dir string,
+	logger func(string),
+	sb *sandbox,
+	name string,
+	args ...string,
+) error {
+	cmd := exec.CommandContext(ctx, name, args...)
 	cmd.Dir = dir
+	sb.apply(cmd)
 
 	stdoutPipe, _ := cmd.StdoutPipe()
 	stderrPipe, _ := cmd.StderrPipe()
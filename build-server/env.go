@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/chrollo-lucifer-12/shared/env"
+	"github.com/chrollo-lucifer-12/shared/workflow"
+)
+
+var internalEnvKeys = []string{
+	"DSN",
+	"ENV_ENCRYPTION_KEY",
+	"REDIS_URL",
+	"SUPABASE_ACCESS_KEY",
+	"SUPABASE_SECRET_KEY",
+	"BUILD_SECRETS_FILE",
+	"BUILD_SECRETS_KEY",
+}
+
+func secretsPath() string {
+	if path := os.Getenv("BUILD_SECRETS_FILE"); path != "" {
+		return path
+	}
+	return workflow.BuildSecretsPath
+}
+
+func readBuildSecrets(path, deploymentID string) (workflow.BuildSecrets, error) {
+	var secrets workflow.BuildSecrets
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return secrets, fmt.Errorf("read build secrets: %w", err)
+	}
+	if err := os.Remove(path); err != nil {
+		return secrets, fmt.Errorf("remove build secrets: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return secrets, fmt.Errorf("invalid build secrets: %w", err)
+	}
+	if err := secrets.Unseal(deploymentID); err != nil {
+		return secrets, fmt.Errorf("unseal build secrets: %w", err)
+	}
+
+	return secrets, nil
+}
+
+func stripInternalEnv() {
+	for _, key := range internalEnvKeys {
+		os.Unsetenv(key)
+	}
+}
+
+func applyBuildEnv(vars map[string]string) error {
+	for key := range vars {
+		if env.Reserved(key) {
+			return fmt.Errorf("%s is reserved and cannot be set", key)
+		}
+	}
+	for key, value := range vars {
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
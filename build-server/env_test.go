@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chrollo-lucifer-12/shared/workflow"
+	"gotest.tools/v3/assert"
+)
+
+func writeSecrets(t *testing.T, secrets workflow.BuildSecrets) string {
+	t.Helper()
+
+	data, err := json.Marshal(secrets)
+	assert.NilError(t, err)
+
+	path := filepath.Join(t.TempDir(), workflow.BuildSecretsName)
+	assert.NilError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestReadBuildSecrets(t *testing.T) {
+	path := writeSecrets(t, workflow.BuildSecrets{
+		RedisURL: "redis://localhost:6379",
+		Token:    "token",
+		Env:      map[string]string{"API_URL": "https://example.com"},
+	})
+
+	secrets, err := readBuildSecrets(path, "abc")
+	assert.NilError(t, err)
+	assert.Equal(t, secrets.RedisURL, "redis://localhost:6379")
+	assert.Equal(t, secrets.Token, "token")
+	assert.DeepEqual(t, secrets.Env, map[string]string{"API_URL": "https://example.com"})
+
+	_, err = os.Stat(path)
+	assert.Assert(t, os.IsNotExist(err))
+
+	_, err = readBuildSecrets(path, "abc")
+	assert.ErrorContains(t, err, "read build secrets")
+}
+
+func TestReadSealedBuildSecrets(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	sealed, err := workflow.SealInputs(key, workflow.Input{
+		DeploymentID: "abc",
+		Token:        "token",
+		Env:          map[string]string{"API_URL": "https://example.com"},
+	})
+	assert.NilError(t, err)
+
+	secrets, err := readBuildSecrets(writeSecrets(t, workflow.BuildSecrets{Sealed: sealed, SealKey: key}), "abc")
+	assert.NilError(t, err)
+	assert.Equal(t, secrets.Token, "token")
+	assert.DeepEqual(t, secrets.Env, map[string]string{"API_URL": "https://example.com"})
+	assert.Equal(t, secrets.SealKey, "")
+
+	_, err = readBuildSecrets(writeSecrets(t, workflow.BuildSecrets{Sealed: sealed, SealKey: key}), "other")
+	assert.ErrorContains(t, err, "unseal build secrets")
+}
+
+func TestApplyBuildEnvRejectsReservedNames(t *testing.T) {
+	for _, name := range []string{"PATH", "NODE_OPTIONS", "DSN", "REDIS_URL", "DEPLOYMENT_ID", "path"} {
+		err := applyBuildEnv(map[string]string{name: "x"})
+		assert.ErrorContains(t, err, "reserved", name)
+	}
+
+	t.Setenv("BUILD_TEST_VALUE", "")
+	assert.NilError(t, applyBuildEnv(map[string]string{"BUILD_TEST_VALUE": "set"}))
+	assert.Equal(t, os.Getenv("BUILD_TEST_VALUE"), "set")
+}
+
+func TestStripInternalEnv(t *testing.T) {
+	for _, key := range internalEnvKeys {
+		t.Setenv(key, "secret")
+	}
+
+	stripInternalEnv()
+
+	for _, key := range internalEnvKeys {
+		_, ok := os.LookupEnv(key)
+		assert.Assert(t, !ok, key)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/chrollo-lucifer-12/shared/functions"
+)
+
+const maxFunctionSize = 32 << 20
+
+func functionSources(root string) ([]string, error) {
+	dir := filepath.Join(root, functions.Dir)
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() {
+			if strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+				sources = append(sources, filepath.Join(functions.Dir, name))
+			}
+			continue
+		}
+
+		files, err := filepath.Glob(filepath.Join(dir, name, "*.go"))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) > 0 {
+			sources = append(sources, filepath.Join(functions.Dir, name))
+		}
+	}
+
+	return sources, nil
+}
+
+func buildFunctions(ctx context.Context, root string, logger func(string)) ([]functions.Function, error) {
+	sources, err := functionSources(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sources) > 0 {
+		tinygo, lookErr := exec.LookPath("tinygo")
+
+		for _, source := range sources {
+			output := strings.TrimSuffix(source, ".go") + functions.Extension
+
+			if lookErr != nil {
+				if _, err := os.Stat(filepath.Join(root, output)); err == nil {
+					logger("tinygo is not installed, using prebuilt " + output)
+					continue
+				}
+				return nil, fmt.Errorf("%s needs tinygo to build and no prebuilt %s was found", source, output)
+			}
+
+			logger("Compiling function " + source + " with tinygo...")
+			if err := RunCommand(ctx, root, logger, tinygo, "build", "-o", output, "-target=wasip1", "-no-debug", "./"+filepath.ToSlash(source)); err != nil {
+				return nil, fmt.Errorf("compiling %s: %w", source, err)
+			}
+		}
+	}
+
+	found, err := functions.Collect(root)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fn := range found {
+		info, err := os.Stat(fn.File)
+		if err != nil {
+			return nil, err
+		}
+		if info.Size() > maxFunctionSize {
+			return nil, fmt.Errorf("%s is larger than %d MB", fn.Route, maxFunctionSize>>20)
+		}
+	}
+
+	return found, nil
+}
@@ -2,4 +2,9 @@ module github.com/chrollo-lucifer-12/build-server
 
 go 1.24.3
 
-require github.com/google/uuid v1.6.0
+require (
+	github.com/google/uuid v1.6.0
+	gotest.tools/v3 v3.5.2
+)
+
+require github.com/google/go-cmp v0.7.0 // indirect
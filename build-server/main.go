@@ -2,86 +2,50 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
-	"time"
+	"path/filepath"
 
-	"github.com/chrollo-lucifer-12/shared/db"
+	"github.com/chrollo-lucifer-12/shared/caching"
+	"github.com/chrollo-lucifer-12/shared/framework"
+	"github.com/chrollo-lucifer-12/shared/functions"
+	"github.com/chrollo-lucifer-12/shared/queue"
 	"github.com/chrollo-lucifer-12/shared/redis"
+	"github.com/chrollo-lucifer-12/shared/routing"
 	"github.com/chrollo-lucifer-12/shared/storage"
 	"github.com/chrollo-lucifer-12/shared/utils"
 	"github.com/google/uuid"
 )
 
-func pushStreamLogsToDB(
-	ctx context.Context,
-	redisClient *redis.RedisClient,
-	d *db.DB,
-	streamName string,
-	deploymentId uuid.UUID,
-) {
-
-	time.Sleep(2 * time.Second)
-
-	messages, err := redisClient.XRange(ctx, streamName, "-", "+").Result()
-	if err != nil {
-		fmt.Println("Failed to read stream:", err)
-		return
-	}
-
-	var logs []db.LogEvent
-
-	for _, msg := range messages {
-		if m, ok := msg.Values["message"]; ok {
-			logs = append(logs, db.LogEvent{
-				DeploymentID: deploymentId,
-				Log:          m.(string),
-			})
-		}
-	}
-
-	err = d.CreateLogEvents(ctx, &logs)
-	if err != nil {
-		fmt.Println("Failed to save logs to DB:", err)
-		return
-	}
-
-	redisClient.Del(ctx, streamName)
-
-	fmt.Println("Logs finalized and stream deleted")
-}
-
 func main() {
 	ctx := context.Background()
 
-	dsn := os.Getenv("DSN")
 	slug := os.Getenv("SLUG")
 	region := os.Getenv("REGION")
 	endPoint := os.Getenv("SUPABASE_ENDPOINT")
-	supabaseAccessKey := os.Getenv("SUPABASE_ACCESS_KEY")
-	supabaseSecret := os.Getenv("SUPABASE_SECRET_KEY")
-	//	getUserEnv := os.Getenv("USER_ENV")
 	bucketID := os.Getenv("BUCKET_ID")
 	deploymentId := os.Getenv("DEPLOYMENT_ID")
-	redisURL := os.Getenv("REDIS_URL")
 	deploymentIdUUID, _ := uuid.Parse(deploymentId)
 	streamName := "deployment_logs:" + deploymentId
-	// userEnv, err := utils.ParseUserEnv(getUserEnv)
-	// if err != nil {
-	// 	panic(err)
-	// }
 
-	d, err := db.NewDB(dsn, ctx)
+	secrets, err := readBuildSecrets(secretsPath(), deploymentId)
+	stripInternalEnv()
 	if err != nil {
-		panic(err)
+		fmt.Println(err)
+		os.Exit(1)
 	}
 
-	redisClient := redis.NewRedisClient(redisURL)
+	buildEnv := secrets.Env
 
-	updateDeploymentFunc := func(status string) {
-		d.UpdateDeployment(ctx, deploymentIdUUID, db.Deployment{Status: status})
+	result := queue.BuildResult{
+		DeploymentID: deploymentId,
+		Token:        secrets.Token,
 	}
 
+	redisClient := redis.NewRedisClient(secrets.RedisURL)
+	queueClient := queue.NewAsynqClient(secrets.RedisURL)
+
 	logger := func(message string) {
 		_, err := redisClient.StreamAdd(ctx, streamName, map[string]interface{}{
 			"message": message,
@@ -91,55 +55,177 @@ func main() {
 		}
 	}
 
-	finalizeLogs := func() {
-		pushStreamLogsToDB(
-			ctx,
-			redisClient,
-			d,
-			streamName,
-			deploymentIdUUID,
-		)
+	report := func(status string) {
+		result.Status = status
+		if _, err := queueClient.NewBuildResultTask(result); err != nil {
+			fmt.Println("Failed to report build result:", err)
+			os.Exit(1)
+		}
 	}
 
-	s, err := storage.NewS3Storage(endPoint, supabaseAccessKey, supabaseSecret, region, bucketID)
+	s, err := storage.NewS3Storage(endPoint, secrets.SupabaseAccessKey, secrets.SupabaseSecretKey, region, bucketID)
 	if err != nil {
-		updateDeploymentFunc("FAILED")
 		fmt.Println(err)
+		report("FAILED")
 		return
 	}
 
-	logger("Running npm install/build...")
-	outputDir := utils.GetPath([]string{"home", "app", "output"})
+	builder, err = lookupBuilder()
+	if err != nil {
+		logger(err.Error())
+		report("FAILED")
+		return
+	}
+
+	outputDir := os.Getenv("WORK_DIR")
+	if outputDir == "" {
+		outputDir = utils.GetPath([]string{"home", "app", "output"})
+	}
 
-	err = RunNpmCommand(ctx, outputDir, streamName, logger, "install")
+	settings, err := framework.ParseSettings(os.Getenv("BUILD_SETTINGS"))
 	if err != nil {
-		logger("npm install failed: " + err.Error())
-		updateDeploymentFunc("FAILED")
-		finalizeLogs()
+		logger(err.Error())
+		report("FAILED")
+		return
+	}
+
+	projectDir := filepath.Join(outputDir, settings.RootDirectory)
+	if info, err := os.Stat(projectDir); err != nil || !info.IsDir() {
+		logger("root directory " + settings.RootDirectory + " does not exist in the repository")
+		report("FAILED")
+		return
+	}
+
+	if len(buildEnv) > 0 {
+		err := applyBuildEnv(buildEnv)
+		if err == nil {
+			err = utils.WriteEnvFile(projectDir, buildEnv)
+		}
+		if err != nil {
+			logger("failed to apply environment variables: " + err.Error())
+			report("FAILED")
+			return
+		}
+		logger(fmt.Sprintf("Loaded %d environment variables", len(buildEnv)))
+	}
+
+	if err := builder.own(outputDir); err != nil {
+		logger("failed to prepare the build directory: " + err.Error())
+		report("FAILED")
+		return
+	}
+
+	if err := setupNode(ctx, projectDir, settings.NodeVersion, logger); err != nil {
+		logger("node setup failed: " + err.Error())
+		report("FAILED")
 		return
 	}
 
-	err = RunNpmCommand(ctx, outputDir, streamName, logger, "run", "build")
+	routes, err := routing.Load(projectDir)
 	if err != nil {
-		logger("npm build failed: " + err.Error())
-		updateDeploymentFunc("FAILED")
-		finalizeLogs()
+		logger("invalid routing config: " + err.Error())
+		report("FAILED")
 		return
 	}
 
-	if err := s.UploadDirectory(ctx, "/home/app/output/dist", slug, deploymentIdUUID, logger); err != nil {
+	if !routes.Empty() {
+		data, err := json.Marshal(routes)
+		if err != nil {
+			logger("failed to save routing config: " + err.Error())
+			report("FAILED")
+			return
+		}
+		result.Routes = data
+		logger(fmt.Sprintf("Loaded %s: %d redirects, %d rewrites, %d header rules", routing.ConfigFile, len(routes.Redirects), len(routes.Rewrites), len(routes.Headers)))
+	}
+
+	fw, err := framework.Detect(projectDir, os.Getenv("FRAMEWORK"))
+	if err != nil {
+		logger("framework detection failed: " + err.Error())
+		report("FAILED")
+		return
+	}
+
+	fw = settings.Apply(fw)
+
+	logger("Detected framework: " + fw.String())
+
+	var pm PackageManager
+	if fw.Install || fw.Script != "" {
+		pm, err = detectPackageManager(projectDir, outputDir)
+		if err != nil {
+			logger("package manager detection failed: " + err.Error())
+			report("FAILED")
+			return
+		}
+
+		version, err := pm.version(ctx, projectDir)
+		if err != nil {
+			logger(pm.Name + " is not available: " + err.Error())
+			report("FAILED")
+			return
+		}
+		pm.Version = version
+
+		source := "default"
+		if pm.Lockfile != "" {
+			source = pm.Lockfile
+		}
+		logger(fmt.Sprintf("Using %s (%s)", pm, source))
+
+		result.PackageManager = pm.String()
+	}
+
+	if err := runBuild(ctx, projectDir, fw, pm, logger); err != nil {
+		logger("build failed: " + err.Error())
+		report("FAILED")
+		return
+	}
+
+	distDir, err := fw.Output(projectDir)
+	if err != nil {
+		logger("build failed: " + err.Error())
+		report("FAILED")
+		return
+	}
+
+	fns, err := buildFunctions(ctx, projectDir, logger)
+	if err != nil {
+		logger("function build failed: " + err.Error())
+		report("FAILED")
+		return
+	}
+
+	uploadOptions := storage.UploadOptions{
+		Compress:      os.Getenv("COMPRESS_ASSETS") != "false",
+		Fingerprinted: caching.IsFingerprinted,
+	}
+
+	if err := s.UploadDirectory(ctx, distDir, slug, deploymentIdUUID, uploadOptions, logger); err != nil {
 		fmt.Println("build upload failed: " + err.Error())
 		logger("build upload failed: " + err.Error())
-		updateDeploymentFunc("FAILED")
-		finalizeLogs()
+		report("FAILED")
 		return
 	}
 
-	logger("build successful!")
+	if len(fns) > 0 {
+		functionRoutes := make([]string, 0, len(fns))
+		for _, fn := range fns {
+			if err := s.UploadFile(ctx, fn.File, functions.ObjectKey(slug, fn.Route), "application/wasm"); err != nil {
+				logger("function upload failed: " + fn.Route + " -> " + err.Error())
+				report("FAILED")
+				return
+			}
+			logger("Uploaded function " + fn.Route)
+			functionRoutes = append(functionRoutes, fn.Route)
+		}
+
+		result.Functions = functionRoutes
+	}
 
-	updateDeploymentFunc("SUCCESS")
+	logger("build successful!")
 
-	finalizeLogs()
+	report("SUCCESS")
 
 	os.Exit(0)
 }
@@ -1,9 +1,19 @@
 #!/bin/sh
 set -e
 
-mkdir -p /home/app/output
-cd /home/app/output
+WORK_DIR="${WORK_DIR:-/home/app/output}"
+export WORK_DIR
 
-git -c core.compression=0 clone --progress --depth 1 --single-branch "$GIT_REPOSITORY_URL" .
+case "$GIT_REPOSITORY_URL" in
+  -*)
+    echo "invalid git URL: $GIT_REPOSITORY_URL" >&2
+    exit 1
+    ;;
+esac
 
-/bs
+mkdir -p "$WORK_DIR"
+cd "$WORK_DIR"
+
+git -c core.compression=0 clone --progress --depth 1 --single-branch -- "$GIT_REPOSITORY_URL" .
+
+exec /bs
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/chrollo-lucifer-12/shared/framework"
+)
+
+type PackageManager struct {
+	Name     string
+	Version  string
+	Lockfile string
+	berry    bool
+}
+
+var lockfiles = []struct {
+	file string
+	name string
+}{
+	{"bun.lockb", "bun"},
+	{"bun.lock", "bun"},
+	{"pnpm-lock.yaml", "pnpm"},
+	{"yarn.lock", "yarn"},
+	{"package-lock.json", "npm"},
+	{"npm-shrinkwrap.json", "npm"},
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func lockfileDir(dir, root string) string {
+	for current := dir; ; current = filepath.Dir(current) {
+		for _, lock := range lockfiles {
+			if fileExists(filepath.Join(current, lock.file)) {
+				return current
+			}
+		}
+		if current == root || !strings.HasPrefix(current, root) || current == filepath.Dir(current) {
+			return dir
+		}
+	}
+}
+
+func detectPackageManager(dir, root string) (PackageManager, error) {
+	pkg, err := framework.ReadPackageJSON(dir)
+	if err != nil {
+		return PackageManager{}, err
+	}
+
+	var pm PackageManager
+
+	if pkg != nil && pkg.PackageManager != "" {
+		name, version, _ := strings.Cut(pkg.PackageManager, "@")
+		version, _, _ = strings.Cut(version, "+")
+		switch name {
+		case "npm", "yarn", "pnpm", "bun":
+		default:
+			return PackageManager{}, fmt.Errorf("unsupported packageManager %q", pkg.PackageManager)
+		}
+		pm.Name = name
+		pm.Version = version
+	}
+
+	lockDir := lockfileDir(dir, root)
+
+	for _, lock := range lockfiles {
+		if pm.Name != "" && lock.name != pm.Name {
+			continue
+		}
+		if fileExists(filepath.Join(lockDir, lock.file)) {
+			pm.Name = lock.name
+			pm.Lockfile = lock.file
+			break
+		}
+	}
+
+	if pm.Name == "" {
+		pm.Name = "npm"
+	}
+
+	if pm.Name == "yarn" {
+		pm.berry = fileExists(filepath.Join(lockDir, ".yarnrc.yml")) ||
+			(pm.Version != "" && !strings.HasPrefix(pm.Version, "1."))
+	}
+
+	return pm, nil
+}
+
+func (pm PackageManager) command() string {
+	if runtime.GOOS == "windows" && pm.Name != "bun" {
+		return pm.Name + ".cmd"
+	}
+	return pm.Name
+}
+
+func (pm PackageManager) installArgs() []string {
+	if pm.Lockfile == "" {
+		return []string{"install"}
+	}
+
+	switch pm.Name {
+	case "npm":
+		return []string{"ci"}
+	case "yarn":
+		if pm.berry {
+			return []string{"install", "--immutable"}
+		}
+		return []string{"install", "--frozen-lockfile"}
+	default:
+		return []string{"install", "--frozen-lockfile"}
+	}
+}
+
+func (pm PackageManager) version(ctx context.Context, dir string) (string, error) {
+	cmd := exec.CommandContext(ctx, pm.command(), "--version")
+	cmd.Dir = dir
+	builder.apply(cmd)
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (pm PackageManager) String() string {
+	if pm.Version == "" {
+		return pm.Name
+	}
+	return pm.Name + "@" + pm.Version
+}
+
+func (pm PackageManager) Install(ctx context.Context, dir string, logger func(string)) error {
+	args := pm.installArgs()
+	logger("Running " + pm.Name + " " + strings.Join(args, " ") + "...")
+	return RunCommand(ctx, dir, logger, pm.command(), args...)
+}
+
+func (pm PackageManager) Run(ctx context.Context, dir string, logger func(string), script string) error {
+	logger("Running " + pm.Name + " run " + script + "...")
+	return RunCommand(ctx, dir, logger, pm.command(), "run", script)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NilError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NilError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestDetectPackageManagerFromLockfile(t *testing.T) {
+	for _, tc := range []struct {
+		lockfile string
+		name     string
+		install  []string
+	}{
+		{"package-lock.json", "npm", []string{"ci"}},
+		{"npm-shrinkwrap.json", "npm", []string{"ci"}},
+		{"yarn.lock", "yarn", []string{"install", "--frozen-lockfile"}},
+		{"pnpm-lock.yaml", "pnpm", []string{"install", "--frozen-lockfile"}},
+		{"bun.lock", "bun", []string{"install", "--frozen-lockfile"}},
+		{"bun.lockb", "bun", []string{"install", "--frozen-lockfile"}},
+	} {
+		dir := writeFiles(t, map[string]string{"package.json": "{}", tc.lockfile: ""})
+
+		pm, err := detectPackageManager(dir, dir)
+		assert.NilError(t, err)
+		assert.Equal(t, pm.Name, tc.name, tc.lockfile)
+		assert.Equal(t, pm.Lockfile, tc.lockfile)
+		assert.DeepEqual(t, pm.installArgs(), tc.install)
+	}
+}
+
+func TestDetectPackageManagerWithoutLockfile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"package.json": "{}"})
+
+	pm, err := detectPackageManager(dir, dir)
+	assert.NilError(t, err)
+	assert.Equal(t, pm.Name, "npm")
+	assert.DeepEqual(t, pm.installArgs(), []string{"install"})
+}
+
+func TestDetectPackageManagerField(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"package.json":      `{"packageManager":"pnpm@9.1.0+sha512.abc"}`,
+		"package-lock.json": "",
+		"pnpm-lock.yaml":    "",
+	})
+
+	pm, err := detectPackageManager(dir, dir)
+	assert.NilError(t, err)
+	assert.Equal(t, pm.String(), "pnpm@9.1.0")
+	assert.Equal(t, pm.Lockfile, "pnpm-lock.yaml")
+
+	dir = writeFiles(t, map[string]string{"package.json": `{"packageManager":"deno@2"}`})
+	_, err = detectPackageManager(dir, dir)
+	assert.ErrorContains(t, err, "unsupported packageManager")
+}
+
+func TestDetectYarnBerry(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"package.json": `{"packageManager":"yarn@4.1.0"}`, "yarn.lock": ""})
+	pm, err := detectPackageManager(dir, dir)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, pm.installArgs(), []string{"install", "--immutable"})
+
+	dir = writeFiles(t, map[string]string{"package.json": "{}", "yarn.lock": "", ".yarnrc.yml": ""})
+	pm, err = detectPackageManager(dir, dir)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, pm.installArgs(), []string{"install", "--immutable"})
+
+	dir = writeFiles(t, map[string]string{"package.json": `{"packageManager":"yarn@1.22.19"}`, "yarn.lock": ""})
+	pm, err = detectPackageManager(dir, dir)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, pm.installArgs(), []string{"install", "--frozen-lockfile"})
+}
+
+func TestDetectPackageManagerInWorkspace(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"pnpm-lock.yaml":        "",
+		"apps/web/package.json": "{}",
+	})
+	app := filepath.Join(root, "apps", "web")
+
+	assert.Equal(t, lockfileDir(app, root), root)
+
+	pm, err := detectPackageManager(app, root)
+	assert.NilError(t, err)
+	assert.Equal(t, pm.Name, "pnpm")
+	assert.Equal(t, pm.Lockfile, "pnpm-lock.yaml")
+
+	outside := writeFiles(t, map[string]string{"package.json": "{}"})
+	assert.Equal(t, lockfileDir(outside, root), outside)
+}
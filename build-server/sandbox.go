@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+const builderUser = "builder"
+
+type sandbox struct {
+	uid  int
+	gid  int
+	home string
+}
+
+var builder *sandbox
+
+func lookupBuilder() (*sandbox, error) {
+	if os.Geteuid() != 0 {
+		return nil, nil
+	}
+
+	u, err := user.Lookup(builderUser)
+	if err != nil {
+		return nil, fmt.Errorf("refusing to run the build as root: %w", err)
+	}
+
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return nil, err
+	}
+	gid, err := strconv.Atoi(u.Gid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sandbox{uid: uid, gid: gid, home: u.HomeDir}, nil
+}
+
+func (sb *sandbox) apply(cmd *exec.Cmd) {
+	if sb == nil {
+		return
+	}
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Credential: &syscall.Credential{Uid: uint32(sb.uid), Gid: uint32(sb.gid)},
+	}
+	cmd.Env = append(os.Environ(), "HOME="+sb.home, "USER="+builderUser)
+}
+
+func (sb *sandbox) own(root string) error {
+	if sb == nil {
+		return nil
+	}
+
+	return filepath.WalkDir(root, func(path string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Lchown(path, sb.uid, sb.gid)
+	})
+}
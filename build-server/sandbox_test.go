@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSandboxApply(t *testing.T) {
+	cmd := exec.Command("true")
+	(*sandbox)(nil).apply(cmd)
+	assert.Assert(t, cmd.SysProcAttr == nil)
+	assert.Assert(t, cmd.Env == nil)
+
+	sb := &sandbox{uid: 1000, gid: 1000, home: "/home/builder"}
+	sb.apply(cmd)
+	assert.Equal(t, cmd.SysProcAttr.Credential.Uid, uint32(1000))
+	assert.Equal(t, cmd.SysProcAttr.Credential.Gid, uint32(1000))
+	assert.Equal(t, cmd.Env[len(cmd.Env)-2], "HOME=/home/builder")
+}
+
+func TestSandboxOwn(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "package.json")
+	assert.NilError(t, os.WriteFile(file, []byte("{}"), 0o644))
+	assert.NilError(t, (*sandbox)(nil).own(dir))
+
+	if os.Geteuid() != 0 {
+		t.Skip("changing ownership needs root")
+	}
+
+	assert.NilError(t, (&sandbox{uid: 1234, gid: 1234}).own(dir))
+	info, err := os.Stat(file)
+	assert.NilError(t, err)
+	assert.Equal(t, info.Sys().(*syscall.Stat_t).Uid, uint32(1234))
+}
+
+func TestRunCommandAsBuilder(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("dropping privileges needs root")
+	}
+
+	builder = &sandbox{uid: 1234, gid: 1234, home: t.TempDir()}
+	t.Cleanup(func() { builder = nil })
+
+	var out []string
+	err := RunCommand(context.Background(), "/", func(line string) { out = append(out, line) }, "id", "-u")
+	assert.NilError(t, err)
+	assert.Equal(t, strings.TrimSpace(strings.Join(out, "")), "1234")
+}
@@ -46,7 +46,7 @@ export const createDeployment = async (accessToken: string, slug: string) => {
   try {
     const res = await axiosInstance.post<CreateDeployment>(
       `${clientEnv.NEXT_PUBLIC_CREATE_DEPLOYMENT}`,
-      { user_env: "", project_slug: slug },
+      { project_slug: slug },
       {
         headers: {
           Authorization: `Bearer ${accessToken}`,
@@ -59,7 +59,7 @@ export const getProjects = async (
 export const deleteProject = async (accessToken: string, projectId: string) => {
   try {
     await axiosInstance.delete(
-      `${clientEnv.NEXT_PUBLIC_DELETE_PROJECT}/${projectId}`,
+      `${clientEnv.NEXT_PUBLIC_GET_PROJECT}/${projectId}`,
       {
         headers: {
           Authorization: `Bearer ${accessToken}`,
@@ -97,7 +97,7 @@ export const getProjectAnalytics = async (
 ) => {
   try {
     const res = await axiosInstance.get<WebsiteAnalytics[]>(
-      `${clientEnv.NEXT_PUBLIC_GET_ANALYTICS}/${slug}`,
+      `${clientEnv.NEXT_PUBLIC_GET_PROJECT}/${slug}/analytics`,
       {
         headers: {
           Authorization: `Bearer ${accessToken}`,
@@ -11,9 +11,7 @@ const envSchema = z.object({
   NEXT_PUBLIC_ALL_PROJECT_ENDPOINT: z.string().min(1),
   NEXT_PUBLIC_CREATE_PROJECT_ENDPOINT: z.string().min(1),
   NEXT_PUBLIC_BASE_URL: z.string().min(1),
-  NEXT_PUBLIC_DELETE_PROJECT: z.string().min(1),
   NEXT_PUBLIC_GET_PROJECT: z.string().min(1),
-  NEXT_PUBLIC_GET_ANALYTICS: z.string().min(1),
   NEXT_PUBLIC_GET_DEPLOYMENTS: z.string().min(1),
   NEXT_PUBLIC_GET_DEPLOYMENT: z.string().min(1),
   NEXT_PUBLIC_CREATE_DEPLOYMENT: z.string().min(1),
@@ -34,9 +32,7 @@ export const clientEnv = envSchema.parse({
   NEXT_PUBLIC_CREATE_PROJECT_ENDPOINT:
     process.env.NEXT_PUBLIC_CREATE_PROJECT_ENDPOINT,
   NEXT_PUBLIC_BASE_URL: process.env.NEXT_PUBLIC_BASE_URL,
-  NEXT_PUBLIC_DELETE_PROJECT: process.env.NEXT_PUBLIC_DELETE_PROJECT,
   NEXT_PUBLIC_GET_PROJECT: process.env.NEXT_PUBLIC_GET_PROJECT,
-  NEXT_PUBLIC_GET_ANALYTICS: process.env.NEXT_PUBLIC_GET_ANALYTICS,
   NEXT_PUBLIC_GET_DEPLOYMENTS: process.env.NEXT_PUBLIC_GET_DEPLOYMENTS,
   NEXT_PUBLIC_GET_DEPLOYMENT: process.env.NEXT_PUBLIC_GET_DEPLOYMENT,
   NEXT_PUBLIC_CREATE_DEPLOYMENT: process.env.NEXT_PUBLIC_CREATE_DEPLOYMENT,
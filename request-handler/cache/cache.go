@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/chrollo-lucifer-12/shared/redis"
+	"github.com/chrollo-lucifer-12/shared/utils"
+)
+
+const (
+	metaField = "meta"
+	bodyField = "body"
+)
+
+type Meta struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Size   int64       `json:"size"`
+}
+
+type Entry struct {
+	Meta
+	Body []byte
+}
+
+func (e *Entry) footprint() int64 {
+	size := int64(len(e.Body))
+	for k, vv := range e.Header {
+		size += int64(len(k))
+		for _, v := range vv {
+			size += int64(len(v))
+		}
+	}
+	return size
+}
+
+type Config struct {
+	MemoryBytes   int64
+	MaxObjectSize int64
+	TTL           time.Duration
+}
+
+type Stats struct {
+	MemoryHits uint64 `json:"memory_hits"`
+	RedisHits  uint64 `json:"redis_hits"`
+	Misses     uint64 `json:"misses"`
+	Stores     uint64 `json:"stores"`
+	Skipped    uint64 `json:"skipped"`
+}
+
+type Cache struct {
+	rd  *redis.RedisClient
+	mem *lru
+	cfg Config
+
+	memoryHits atomic.Uint64
+	redisHits  atomic.Uint64
+	misses     atomic.Uint64
+	stores     atomic.Uint64
+	skipped    atomic.Uint64
+}
+
+func New(rd *redis.RedisClient, cfg Config) *Cache {
+	if cfg.MemoryBytes <= 0 {
+		cfg.MemoryBytes = 64 << 20
+	}
+	if cfg.MaxObjectSize <= 0 {
+		cfg.MaxObjectSize = 1 << 20
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 10 * time.Minute
+	}
+
+	return &Cache{
+		rd:  rd,
+		mem: newLRU(cfg.MemoryBytes),
+		cfg: cfg,
+	}
+}
+
+func (c *Cache) MaxObjectSize() int64 {
+	return c.cfg.MaxObjectSize
+}
+
+func (c *Cache) Cacheable(size int64) bool {
+	if size < 0 || size > c.cfg.MaxObjectSize {
+		c.skipped.Add(1)
+		return false
+	}
+	return true
+}
+
+func (c *Cache) Skip() {
+	c.skipped.Add(1)
+}
+
+func (c *Cache) Get(ctx context.Context, key string) (*Entry, bool) {
+	if entry, ok := c.mem.get(key); ok {
+		c.memoryHits.Add(1)
+		return entry, true
+	}
+
+	values, err := c.rd.HMGet(ctx, utils.GetObjectCacheKey(key), metaField, bodyField)
+	if err != nil || len(values) != 2 {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	rawMeta, ok := values[0].(string)
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+	rawBody, _ := values[1].(string)
+
+	var entry Entry
+	if err := json.Unmarshal([]byte(rawMeta), &entry.Meta); err != nil || int64(len(rawBody)) != entry.Size {
+		c.misses.Add(1)
+		return nil, false
+	}
+	entry.Body = []byte(rawBody)
+
+	c.mem.add(key, &entry, c.cfg.TTL)
+	c.redisHits.Add(1)
+
+	return &entry, true
+}
+
+func (c *Cache) Set(ctx context.Context, key string, entry *Entry) {
+	entry.Size = int64(len(entry.Body))
+	if !c.Cacheable(entry.Size) {
+		return
+	}
+
+	meta, err := json.Marshal(entry.Meta)
+	if err != nil {
+		return
+	}
+
+	c.mem.add(key, entry, c.cfg.TTL)
+	c.stores.Add(1)
+
+	_ = c.rd.HSetWithTTL(ctx, utils.GetObjectCacheKey(key), c.cfg.TTL, metaField, meta, bodyField, entry.Body)
+}
+
+func (c *Cache) InvalidatePrefix(ctx context.Context, prefix string) error {
+	c.mem.removePrefix(prefix + "/")
+	return c.rd.DeleteByPattern(ctx, utils.GetDeploymentCachePattern(prefix))
+}
+
+func (c *Cache) Stats() Stats {
+	return Stats{
+		MemoryHits: c.memoryHits.Load(),
+		RedisHits:  c.redisHits.Load(),
+		Misses:     c.misses.Load(),
+		Stores:     c.stores.Load(),
+		Skipped:    c.skipped.Load(),
+	}
+}
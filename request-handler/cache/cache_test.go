@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/chrollo-lucifer-12/shared/redis"
+	"github.com/chrollo-lucifer-12/shared/utils"
+	"gotest.tools/v3/assert"
+)
+
+func newTestCache(t *testing.T, cfg Config) (*Cache, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	return New(redis.NewRedisClient("redis://"+mr.Addr()), cfg), mr
+}
+
+func entry(body string) *Entry {
+	return &Entry{
+		Meta: Meta{Status: http.StatusOK, Header: http.Header{"Content-Type": {"text/plain"}}},
+		Body: []byte(body),
+	}
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	l := newLRU(10)
+
+	l.add("a", &Entry{Body: []byte("aaaa")}, time.Minute)
+	l.add("b", &Entry{Body: []byte("bbbb")}, time.Minute)
+
+	_, ok := l.get("a")
+	assert.Assert(t, ok)
+
+	l.add("c", &Entry{Body: []byte("cccc")}, time.Minute)
+
+	_, ok = l.get("b")
+	assert.Assert(t, !ok)
+	_, ok = l.get("a")
+	assert.Assert(t, ok)
+	_, ok = l.get("c")
+	assert.Assert(t, ok)
+	assert.Equal(t, l.size, int64(8))
+}
+
+func TestLRUExpiresAndRejectsOversized(t *testing.T) {
+	l := newLRU(10)
+
+	l.add("expired", &Entry{Body: []byte("x")}, -time.Second)
+	_, ok := l.get("expired")
+	assert.Assert(t, !ok)
+	assert.Equal(t, l.size, int64(0))
+
+	l.add("big", &Entry{Body: make([]byte, 11)}, time.Minute)
+	_, ok = l.get("big")
+	assert.Assert(t, !ok)
+}
+
+func TestLRURemovePrefix(t *testing.T) {
+	l := newLRU(100)
+
+	l.add("blog1/index.html", &Entry{Body: []byte("a")}, time.Minute)
+	l.add("blog1/app.js", &Entry{Body: []byte("b")}, time.Minute)
+	l.add("blog12/index.html", &Entry{Body: []byte("c")}, time.Minute)
+
+	l.removePrefix("blog1/")
+
+	_, ok := l.get("blog1/index.html")
+	assert.Assert(t, !ok)
+	_, ok = l.get("blog12/index.html")
+	assert.Assert(t, ok)
+	assert.Equal(t, l.size, int64(1))
+}
+
+func TestCacheReadsThroughRedis(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+
+	writer := New(redis.NewRedisClient("redis://"+mr.Addr()), Config{})
+	writer.Set(ctx, "blog1/index.html", entry("hello"))
+
+	assert.Assert(t, mr.Exists(utils.GetObjectCacheKey("blog1/index.html")))
+
+	reader := New(redis.NewRedisClient("redis://"+mr.Addr()), Config{})
+
+	got, ok := reader.Get(ctx, "blog1/index.html")
+	assert.Assert(t, ok)
+	assert.Equal(t, string(got.Body), "hello")
+	assert.Equal(t, got.Status, http.StatusOK)
+	assert.Equal(t, got.Header.Get("Content-Type"), "text/plain")
+
+	_, ok = reader.Get(ctx, "blog1/index.html")
+	assert.Assert(t, ok)
+
+	_, ok = reader.Get(ctx, "blog1/missing.html")
+	assert.Assert(t, !ok)
+
+	stats := reader.Stats()
+	assert.Equal(t, stats.RedisHits, uint64(1))
+	assert.Equal(t, stats.MemoryHits, uint64(1))
+	assert.Equal(t, stats.Misses, uint64(1))
+}
+
+func TestCacheSkipsLargeObjects(t *testing.T) {
+	ctx := context.Background()
+	c, mr := newTestCache(t, Config{MaxObjectSize: 4})
+
+	c.Set(ctx, "blog1/big.js", entry("too large"))
+
+	_, ok := c.Get(ctx, "blog1/big.js")
+	assert.Assert(t, !ok)
+	assert.Assert(t, !mr.Exists(utils.GetObjectCacheKey("blog1/big.js")))
+	assert.Equal(t, c.Stats().Skipped, uint64(1))
+}
+
+func TestCacheIgnoresTruncatedRedisEntries(t *testing.T) {
+	ctx := context.Background()
+	c, mr := newTestCache(t, Config{})
+
+	c.Set(ctx, "blog1/index.html", entry("hello"))
+	mr.HSet(utils.GetObjectCacheKey("blog1/index.html"), bodyField, "hel")
+
+	fresh := New(redis.NewRedisClient("redis://"+mr.Addr()), Config{})
+	_, ok := fresh.Get(ctx, "blog1/index.html")
+	assert.Assert(t, !ok)
+}
+
+func TestCacheInvalidatePrefix(t *testing.T) {
+	ctx := context.Background()
+	c, mr := newTestCache(t, Config{})
+
+	c.Set(ctx, "blog1/index.html", entry("old"))
+	c.Set(ctx, "blog2/index.html", entry("new"))
+
+	assert.NilError(t, c.InvalidatePrefix(ctx, "blog1"))
+
+	_, ok := c.Get(ctx, "blog1/index.html")
+	assert.Assert(t, !ok)
+	assert.Assert(t, !mr.Exists(utils.GetObjectCacheKey("blog1/index.html")))
+
+	_, ok = c.Get(ctx, "blog2/index.html")
+	assert.Assert(t, ok)
+}
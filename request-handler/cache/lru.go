@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+type lruItem struct {
+	key       string
+	entry     *Entry
+	size      int64
+	expiresAt time.Time
+}
+
+type lru struct {
+	mu       sync.Mutex
+	capacity int64
+	size     int64
+	items    map[string]*list.Element
+	order    *list.List
+}
+
+func newLRU(capacity int64) *lru {
+	return &lru{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (l *lru) get(key string) (*Entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	el, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	item := el.Value.(*lruItem)
+	if time.Now().After(item.expiresAt) {
+		l.removeElement(el)
+		return nil, false
+	}
+
+	l.order.MoveToFront(el)
+	return item.entry, true
+}
+
+func (l *lru) add(key string, entry *Entry, ttl time.Duration) {
+	size := entry.footprint()
+	if size > l.capacity {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if el, ok := l.items[key]; ok {
+		l.removeElement(el)
+	}
+
+	el := l.order.PushFront(&lruItem{
+		key:       key,
+		entry:     entry,
+		size:      size,
+		expiresAt: time.Now().Add(ttl),
+	})
+	l.items[key] = el
+	l.size += size
+
+	for l.size > l.capacity {
+		l.removeElement(l.order.Back())
+	}
+}
+
+func (l *lru) removePrefix(prefix string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, el := range l.items {
+		if strings.HasPrefix(key, prefix) {
+			l.removeElement(el)
+		}
+	}
+}
+
+func (l *lru) removeElement(el *list.Element) {
+	item := el.Value.(*lruItem)
+	l.order.Remove(el)
+	delete(l.items, item.key)
+	l.size -= item.size
+}
@@ -1,3 +1,14 @@
 module github.com/chrollo-lucider-12/proxy
 
 go 1.24.3
+
+require github.com/tetratelabs/wazero v1.9.0
+
+require (
+	github.com/HugoSmits86/nativewebp v0.9.3
+	github.com/alicebob/miniredis/v2 v2.39.0
+	golang.org/x/image v0.36.0
+	gotest.tools/v3 v3.5.2
+)
+
+require github.com/yuin/gopher-lua v1.1.1 // indirect
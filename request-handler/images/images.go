@@ -0,0 +1,139 @@
+package images
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+	FormatGIF  = "gif"
+)
+
+var (
+	ErrUnsupported = errors.New("unsupported image format")
+	ErrTooLarge    = errors.New("image dimensions are too large")
+)
+
+var contentTypes = map[string]string{
+	FormatJPEG: "image/jpeg",
+	FormatPNG:  "image/png",
+	FormatWebP: "image/webp",
+	FormatGIF:  "image/gif",
+}
+
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+type Options struct {
+	Width     int
+	Quality   int
+	Format    string
+	MaxPixels int
+}
+
+func Inspect(data []byte) (image.Config, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return image.Config{}, "", ErrUnsupported
+	}
+	return cfg, format, nil
+}
+
+func Negotiate(webp bool, source string) string {
+	switch {
+	case source == FormatJPEG || source == FormatGIF:
+		return source
+	case webp:
+		return FormatWebP
+	default:
+		return FormatPNG
+	}
+}
+
+func AcceptsWebP(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+		if strings.TrimSpace(mediaType) != "image/webp" {
+			continue
+		}
+
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if key == "q" {
+				q, err := strconv.ParseFloat(value, 64)
+				return err == nil && q > 0
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func Transform(data []byte, opts Options) ([]byte, error) {
+	cfg, _, err := Inspect(data)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Format == FormatGIF {
+		return data, nil
+	}
+	if opts.MaxPixels > 0 && cfg.Width*cfg.Height > opts.MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+
+	img := resize(src, opts.Width)
+
+	var buf bytes.Buffer
+	if err := encode(&buf, img, opts); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func resize(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+	if width <= 0 || width >= bounds.Dx() {
+		return src
+	}
+
+	height := max(1, bounds.Dy()*width/bounds.Dx())
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	return dst
+}
+
+func encode(w io.Writer, img image.Image, opts Options) error {
+	switch opts.Format {
+	case FormatJPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: opts.Quality})
+	case FormatPNG:
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		return encoder.Encode(w, img)
+	case FormatWebP:
+		return nativewebp.Encode(w, img, nil)
+	}
+	return ErrUnsupported
+}
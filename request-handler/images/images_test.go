@@ -0,0 +1,105 @@
+package images
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func testImage(width, height int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	assert.NilError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestNegotiate(t *testing.T) {
+	assert.Equal(t, Negotiate(true, FormatJPEG), FormatJPEG)
+	assert.Equal(t, Negotiate(true, FormatPNG), FormatWebP)
+	assert.Equal(t, Negotiate(false, FormatPNG), FormatPNG)
+	assert.Equal(t, Negotiate(true, FormatWebP), FormatWebP)
+	assert.Equal(t, Negotiate(false, FormatWebP), FormatPNG)
+	assert.Equal(t, Negotiate(true, FormatGIF), FormatGIF)
+}
+
+func TestAcceptsWebP(t *testing.T) {
+	assert.Assert(t, AcceptsWebP("image/avif,image/webp,*/*"))
+	assert.Assert(t, AcceptsWebP("image/webp;q=0.5"))
+	assert.Assert(t, !AcceptsWebP("image/webp;q=0"))
+	assert.Assert(t, !AcceptsWebP("image/png,*/*"))
+}
+
+func TestTransformResizes(t *testing.T) {
+	data := encodePNG(t, testImage(200, 100))
+
+	for _, format := range []string{FormatPNG, FormatWebP, FormatJPEG} {
+		out, err := Transform(data, Options{Width: 50, Quality: 75, Format: format})
+		assert.NilError(t, err)
+
+		cfg, decoded, err := Inspect(out)
+		assert.NilError(t, err)
+		assert.Equal(t, decoded, format)
+		assert.Equal(t, cfg.Width, 50)
+		assert.Equal(t, cfg.Height, 25)
+	}
+}
+
+func TestTransformDoesNotUpscale(t *testing.T) {
+	out, err := Transform(encodePNG(t, testImage(20, 10)), Options{Width: 640, Format: FormatPNG})
+	assert.NilError(t, err)
+
+	cfg, _, err := Inspect(out)
+	assert.NilError(t, err)
+	assert.Equal(t, cfg.Width, 20)
+}
+
+func TestTransformQuality(t *testing.T) {
+	data := encodePNG(t, testImage(200, 200))
+
+	low, err := Transform(data, Options{Width: 200, Quality: 25, Format: FormatJPEG})
+	assert.NilError(t, err)
+	high, err := Transform(data, Options{Width: 200, Quality: 100, Format: FormatJPEG})
+	assert.NilError(t, err)
+
+	assert.Assert(t, len(low) < len(high))
+	_, err = jpeg.Decode(bytes.NewReader(low))
+	assert.NilError(t, err)
+}
+
+func TestTransformPassesGIFThrough(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+	anim := &gif.GIF{
+		Image: []*image.Paletted{image.NewPaletted(image.Rect(0, 0, 4, 4), palette), image.NewPaletted(image.Rect(0, 0, 4, 4), palette)},
+		Delay: []int{10, 10},
+	}
+
+	var buf bytes.Buffer
+	assert.NilError(t, gif.EncodeAll(&buf, anim))
+
+	out, err := Transform(buf.Bytes(), Options{Width: 2, Format: Negotiate(true, FormatGIF)})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, out, buf.Bytes())
+}
+
+func TestTransformLimits(t *testing.T) {
+	_, err := Transform(encodePNG(t, testImage(100, 100)), Options{Width: 50, Format: FormatPNG, MaxPixels: 1000})
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = Transform([]byte("not an image"), Options{Width: 50, Format: FormatPNG})
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
@@ -3,12 +3,17 @@ package main
 import (
 	"context"
 	"log"
+	"time"
 
+	"github.com/chrollo-lucider-12/proxy/cache"
 	"github.com/chrollo-lucider-12/proxy/server"
+	"github.com/chrollo-lucider-12/proxy/wasm"
+	"github.com/chrollo-lucifer-12/shared/certs"
 	"github.com/chrollo-lucifer-12/shared/db"
 	"github.com/chrollo-lucifer-12/shared/env"
 	"github.com/chrollo-lucifer-12/shared/queue"
 	"github.com/chrollo-lucifer-12/shared/redis"
+	"github.com/chrollo-lucifer-12/shared/security"
 	"github.com/chrollo-lucifer-12/shared/storage"
 )
 
@@ -29,7 +34,43 @@ func main() {
 
 	st, err := storage.NewS3Storage(env.SupabaseEndpoint.GetValue(), env.SupabaseAccessKey.GetValue(), env.SupabaseAccessSecret.GetValue(), env.Region.GetValue(), "builds")
 
-	s := server.NewServerClient(db, st, rd, qu)
+	s := server.NewServerClient(db, st, rd, qu, server.Config{
+		BaseDomain:  env.BaseDomain.GetValue(),
+		PathRouting: env.PathRouting.GetValue() == "true",
+		Cache: cache.Config{
+			MemoryBytes:   env.CacheMemoryBytes.GetInt64(),
+			MaxObjectSize: env.CacheMaxObjectBytes.GetInt64(),
+		},
+		MetricsPort:      env.MetricsPort.GetValue(),
+		ProtectionSecret: env.ProtectionSecret.GetValue(),
+		TrustProxy:       env.TrustProxyHeaders.GetValue() == "true",
+		RateLimit: security.Firewall{
+			RequestsPerSecond: float64(env.RateLimitRPS.GetInt64()),
+			Burst:             int(env.RateLimitBurst.GetInt64()),
+		},
+		TLS: server.TLSConfig{
+			Port:     env.TLSPort.GetValue(),
+			CertFile: env.TLSCertFile.GetValue(),
+			KeyFile:  env.TLSKeyFile.GetValue(),
+			ACME: certs.Config{
+				DirectoryURL: env.AcmeDirectoryURL.GetValue(),
+				CABundle:     env.AcmeCABundle.GetValue(),
+				Email:        env.AcmeEmail.GetValue(),
+				RenewBefore:  time.Duration(env.AcmeRenewBeforeHours.GetInt64()) * time.Hour,
+			},
+		},
+		Functions: wasm.Config{
+			Timeout:        time.Duration(env.FunctionTimeoutMs.GetInt64()) * time.Millisecond,
+			MemoryLimitMB:  int(env.FunctionMemoryMB.GetInt64()),
+			MaxConcurrency: int(env.FunctionConcurrency.GetInt64()),
+			MaxBodyBytes:   env.FunctionMaxBodyBytes.GetInt64(),
+		},
+		Images: server.ImageConfig{
+			Widths:         server.ParseImageWidths(env.ImageWidths.GetValue()),
+			MaxSourceBytes: env.ImageMaxSourceBytes.GetInt64(),
+		},
+		EnvEncryptionKey: env.EnvEncryptionKey.GetValue(),
+	})
 
 	if err := s.Run(ctx); err != nil {
 		log.Fatalf("could not start the server: %v", err)
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestEtagMatches(t *testing.T) {
+	assert.Assert(t, etagMatches(`"abc"`, `"abc"`))
+	assert.Assert(t, etagMatches(`"x", W/"abc"`, `"abc"`))
+	assert.Assert(t, etagMatches(`"abc"`, `W/"abc"`))
+	assert.Assert(t, etagMatches(`*`, `"abc"`))
+	assert.Assert(t, !etagMatches(`"abd"`, `"abc"`))
+	assert.Assert(t, !etagMatches(`*`, ""))
+}
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	h := http.Header{}
+	h.Set("ETag", `"v1"`)
+	h.Set("Last-Modified", modified.Format(http.TimeFormat))
+
+	cases := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		want    bool
+	}{
+		{"matching etag", "GET", map[string]string{"If-None-Match": `"v1"`}, true},
+		{"stale etag", "GET", map[string]string{"If-None-Match": `"v0"`}, false},
+		{"etag wins over date", "GET", map[string]string{"If-None-Match": `"v0"`, "If-Modified-Since": modified.Format(http.TimeFormat)}, false},
+		{"not modified since", "HEAD", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, true},
+		{"modified since", "GET", map[string]string{"If-Modified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)}, false},
+		{"invalid date", "GET", map[string]string{"If-Modified-Since": "yesterday"}, false},
+		{"unsafe method", "POST", map[string]string{"If-None-Match": `"v1"`}, false},
+		{"no validators", "GET", nil, false},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest(c.method, "/", nil)
+		for k, v := range c.headers {
+			r.Header.Set(k, v)
+		}
+		assert.Equal(t, notModified(r, h), c.want, c.name)
+	}
+}
+
+func TestWriteNotModified(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Type", "text/html")
+	w.Header().Set("Content-Length", "10")
+	w.Header().Set("ETag", `"v1"`)
+
+	writeNotModified(w)
+
+	assert.Equal(t, w.Code, http.StatusNotModified)
+	assert.Equal(t, w.Header().Get("Content-Type"), "")
+	assert.Equal(t, w.Header().Get("Content-Length"), "")
+	assert.Equal(t, w.Header().Get("ETag"), `"v1"`)
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/chrollo-lucifer-12/shared/storage"
+	"github.com/chrollo-lucifer-12/shared/utils"
+)
+
+const minGzipSize = 1024
+
+var serverEncodings = []string{"br", "gzip"}
+
+var gzipWriters = sync.Pool{
+	New: func() any {
+		return gzip.NewWriter(io.Discard)
+	},
+}
+
+func acceptedEncodings(r *http.Request) []string {
+	header := r.Header.Get("Accept-Encoding")
+	if header == "" {
+		return nil
+	}
+
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "x-gzip" {
+			name = "gzip"
+		}
+
+		weight := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				weight = f
+			}
+		}
+		weights[name] = weight
+	}
+
+	var accepted []string
+	for _, encoding := range serverEncodings {
+		weight, ok := weights[encoding]
+		if !ok {
+			weight, ok = weights["*"]
+		}
+		if ok && weight > 0 {
+			weights[encoding] = weight
+			accepted = append(accepted, encoding)
+		}
+	}
+
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return weights[accepted[i]] > weights[accepted[j]]
+	})
+
+	return accepted
+}
+
+func acceptsEncoding(encodings []string, encoding string) bool {
+	for _, e := range encodings {
+		if e == encoding {
+			return true
+		}
+	}
+	return false
+}
+
+func preferredEncoding(encodings, available []string) string {
+	for _, encoding := range encodings {
+		if acceptsEncoding(available, encoding) {
+			return encoding
+		}
+	}
+	return ""
+}
+
+func (s *ServerClient) encodedFetch(encodings []string) func(context.Context, string) (*storage.Object, error) {
+	return func(ctx context.Context, key string) (*storage.Object, error) {
+		if len(encodings) == 0 || !utils.IsCompressible(utils.DetectContentType(key)) {
+			return s.storage.GetObject(ctx, key)
+		}
+
+		stat, err := s.storage.StatObject(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		encoding := preferredEncoding(encodings, stat.Encodings)
+		if encoding == "" {
+			return s.storage.GetObject(ctx, key)
+		}
+
+		object, err := s.storage.GetObject(ctx, key+storage.EncodingSuffixes[encoding])
+		if storage.IsNotFound(err) {
+			return s.storage.GetObject(ctx, key)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if object.ContentEncoding == "" {
+			object.ContentEncoding = encoding
+		}
+		object.Fingerprinted = stat.Fingerprinted
+
+		return object, nil
+	}
+}
+
+func weakETag(etag string) string {
+	if etag == "" || strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return "W/" + etag
+}
+
+func setEncodingHeaders(h http.Header, path string, object *storage.Object, encodings []string) (gzipBody bool) {
+	if !utils.IsCompressible(utils.DetectContentType(path)) {
+		return false
+	}
+
+	h.Add("Vary", "Accept-Encoding")
+
+	if object.ContentEncoding != "" {
+		h.Set("Content-Encoding", object.ContentEncoding)
+		return false
+	}
+
+	if !acceptsEncoding(encodings, "gzip") || object.ContentLength < minGzipSize {
+		return false
+	}
+
+	h.Set("Content-Encoding", "gzip")
+	h.Del("Content-Length")
+	if etag := h.Get("ETag"); etag != "" {
+		h.Set("ETag", weakETag(etag))
+	}
+
+	return true
+}
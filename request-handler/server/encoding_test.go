@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chrollo-lucifer-12/shared/storage"
+	"gotest.tools/v3/assert"
+)
+
+func TestAcceptedEncodings(t *testing.T) {
+	cases := []struct {
+		header string
+		want   []string
+	}{
+		{"", nil},
+		{"identity", nil},
+		{"gzip, deflate, br", []string{"br", "gzip"}},
+		{"gzip;q=1.0, br;q=0.5", []string{"gzip", "br"}},
+		{"br;q=0, gzip", []string{"gzip"}},
+		{"x-gzip", []string{"gzip"}},
+		{"*;q=0.2, gzip;q=0.8", []string{"gzip", "br"}},
+		{"*;q=0", nil},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		if c.header != "" {
+			r.Header.Set("Accept-Encoding", c.header)
+		}
+		assert.DeepEqual(t, acceptedEncodings(r), c.want)
+	}
+}
+
+func TestPreferredEncoding(t *testing.T) {
+	assert.Equal(t, preferredEncoding([]string{"br", "gzip"}, []string{"gzip", "br"}), "br")
+	assert.Equal(t, preferredEncoding([]string{"br", "gzip"}, []string{"gzip"}), "gzip")
+	assert.Equal(t, preferredEncoding([]string{"gzip"}, []string{"br"}), "")
+	assert.Equal(t, preferredEncoding([]string{"br"}, nil), "")
+}
+
+func TestSetEncodingHeaders(t *testing.T) {
+	h := http.Header{}
+	gzipBody := setEncodingHeaders(h, "/app.js", &storage.Object{ContentEncoding: "br", ContentLength: 4096}, []string{"br"})
+	assert.Assert(t, !gzipBody)
+	assert.Equal(t, h.Get("Content-Encoding"), "br")
+	assert.Equal(t, h.Get("Vary"), "Accept-Encoding")
+
+	h = http.Header{}
+	h.Set("ETag", `"abc"`)
+	h.Set("Content-Length", "4096")
+	gzipBody = setEncodingHeaders(h, "/app.js", &storage.Object{ContentLength: 4096}, []string{"gzip"})
+	assert.Assert(t, gzipBody)
+	assert.Equal(t, h.Get("Content-Encoding"), "gzip")
+	assert.Equal(t, h.Get("Content-Length"), "")
+	assert.Equal(t, h.Get("ETag"), `W/"abc"`)
+
+	h = http.Header{}
+	gzipBody = setEncodingHeaders(h, "/app.js", &storage.Object{ContentLength: 100}, []string{"gzip"})
+	assert.Assert(t, !gzipBody)
+	assert.Equal(t, h.Get("Content-Encoding"), "")
+
+	h = http.Header{}
+	gzipBody = setEncodingHeaders(h, "/logo.png", &storage.Object{ContentLength: 4096}, []string{"gzip"})
+	assert.Assert(t, !gzipBody)
+	assert.Equal(t, h.Get("Vary"), "")
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	gopath "path"
+	"strings"
+
+	"github.com/chrollo-lucifer-12/shared/db"
+	"github.com/chrollo-lucifer-12/shared/storage"
+)
+
+const notFoundPage = "/404.html"
+
+func hasExtension(path string) bool {
+	return gopath.Ext(gopath.Base(path)) != ""
+}
+
+func candidatePaths(path, mode string) []string {
+	candidates := []string{path}
+
+	if hasExtension(path) {
+		return candidates
+	}
+
+	switch mode {
+	case db.ServingModeCleanURLs:
+		candidates = append(candidates, path+".html", strings.TrimSuffix(path, "/")+"/index.html")
+	case db.ServingModeSPA:
+		candidates = append(candidates, "/index.html")
+	}
+
+	return candidates
+}
+
+func (s *ServerClient) openObject(ctx context.Context, site *Site, path string, fetch func(context.Context, string) (*storage.Object, error)) (*storage.Object, string, int, error) {
+	var lastErr error
+
+	for _, candidate := range candidatePaths(path, site.ServingMode) {
+		object, err := fetch(ctx, site.Prefix+candidate)
+		if err == nil {
+			return object, candidate, http.StatusOK, nil
+		}
+		if !storage.IsNotFound(err) {
+			return nil, "", 0, err
+		}
+		lastErr = err
+	}
+
+	object, err := fetch(ctx, site.Prefix+notFoundPage)
+	if err == nil {
+		return object, notFoundPage, http.StatusNotFound, nil
+	}
+	if !storage.IsNotFound(err) {
+		return nil, "", 0, err
+	}
+
+	return nil, "", 0, lastErr
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chrollo-lucifer-12/shared/utils"
+)
+
+const blockedCounterTTL = 30 * 24 * time.Hour
+
+func (s *ServerClient) clientAddr(r *http.Request) netip.Addr {
+	if s.cfg.TrustProxy {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			last := forwarded[len(forwarded)-1]
+			if i := strings.LastIndex(last, ","); i >= 0 {
+				last = last[i+1:]
+			}
+			if addr, err := netip.ParseAddr(strings.TrimSpace(last)); err == nil {
+				return addr.Unmap()
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+func (s *ServerClient) secureRequest(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	return s.cfg.TrustProxy && r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+func (s *ServerClient) clientIP(r *http.Request) string {
+	if addr := s.clientAddr(r); addr.IsValid() {
+		return addr.String()
+	}
+	return r.RemoteAddr
+}
+
+func (s *ServerClient) countBlocked(ctx context.Context, site *Site, reason string) {
+	key := utils.GetBlockedCounterKey(site.ProjectID.String(), time.Now())
+	if err := s.rd.HIncrByWithTTL(ctx, key, reason, 1, blockedCounterTTL); err != nil {
+		log.Printf("Failed to count blocked request for %s: %v", site.SubDomain, err)
+	}
+}
+
+func (s *ServerClient) checkFirewall(w http.ResponseWriter, r *http.Request, site *Site) bool {
+	ctx := r.Context()
+	addr := s.clientAddr(r)
+	if !addr.IsValid() {
+		return true
+	}
+
+	if site.Firewall.Denies(addr) {
+		s.countBlocked(ctx, site, "denied")
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return false
+	}
+
+	limit := site.Firewall
+	if !limit.RateLimited() {
+		limit = s.cfg.RateLimit
+	}
+	if !limit.RateLimited() {
+		return true
+	}
+
+	key := utils.GetRateLimitKey(site.ProjectID.String(), addr.String())
+	allowed, retryAfter, err := s.rd.TakeToken(ctx, key, limit.RequestsPerSecond, limit.Burst)
+	if err != nil {
+		log.Printf("Rate limiter unavailable for %s: %v", site.SubDomain, err)
+		return true
+	}
+	if allowed {
+		return true
+	}
+
+	s.countBlocked(ctx, site, "rate_limited")
+
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	http.Error(w, "too many requests", http.StatusTooManyRequests)
+	return false
+}
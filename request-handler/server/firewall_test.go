@@ -0,0 +1,86 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/chrollo-lucifer-12/shared/redis"
+	"github.com/chrollo-lucifer-12/shared/security"
+	"github.com/google/uuid"
+	"gotest.tools/v3/assert"
+)
+
+func TestClientAddr(t *testing.T) {
+	cases := []struct {
+		trustProxy bool
+		forwarded  []string
+		want       string
+	}{
+		{false, nil, "203.0.113.9"},
+		{false, []string{"198.51.100.1"}, "203.0.113.9"},
+		{true, nil, "203.0.113.9"},
+		{true, []string{"198.51.100.1"}, "198.51.100.1"},
+		{true, []string{"1.1.1.1, 198.51.100.1"}, "198.51.100.1"},
+		{true, []string{"1.1.1.1", "198.51.100.1"}, "198.51.100.1"},
+		{true, []string{"198.51.100.1, garbage"}, "203.0.113.9"},
+		{true, []string{"::ffff:198.51.100.1"}, "198.51.100.1"},
+	}
+
+	for _, c := range cases {
+		s := &ServerClient{cfg: Config{TrustProxy: c.trustProxy}}
+
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "203.0.113.9:51234"
+		for _, v := range c.forwarded {
+			r.Header.Add("X-Forwarded-For", v)
+		}
+
+		assert.Equal(t, s.clientIP(r), c.want)
+	}
+}
+
+func newFirewallServer(t *testing.T, cfg Config) (*ServerClient, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	return &ServerClient{cfg: cfg, rd: redis.NewRedisClient("redis://" + mr.Addr())}, mr
+}
+
+func firewallRequest(ip string) *http.Request {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = ip + ":1234"
+	return r
+}
+
+func TestCheckFirewallDenyList(t *testing.T) {
+	s, _ := newFirewallServer(t, Config{})
+
+	site := &Site{ProjectID: uuid.New(), Firewall: security.Firewall{DenyList: []string{"198.51.100.0/24"}}}
+	site.prepareFirewall()
+
+	w := httptest.NewRecorder()
+	assert.Assert(t, !s.checkFirewall(w, firewallRequest("198.51.100.7"), site))
+	assert.Equal(t, w.Code, http.StatusForbidden)
+
+	assert.Assert(t, s.checkFirewall(httptest.NewRecorder(), firewallRequest("203.0.113.1"), site))
+}
+
+func TestCheckFirewallRateLimit(t *testing.T) {
+	s, _ := newFirewallServer(t, Config{RateLimit: security.Firewall{RequestsPerSecond: 0.001, Burst: 2}})
+
+	site := &Site{ProjectID: uuid.New()}
+
+	assert.Assert(t, s.checkFirewall(httptest.NewRecorder(), firewallRequest("203.0.113.1"), site))
+	assert.Assert(t, s.checkFirewall(httptest.NewRecorder(), firewallRequest("203.0.113.1"), site))
+
+	w := httptest.NewRecorder()
+	assert.Assert(t, !s.checkFirewall(w, firewallRequest("203.0.113.1"), site))
+	assert.Equal(t, w.Code, http.StatusTooManyRequests)
+	assert.Assert(t, w.Header().Get("Retry-After") != "")
+
+	assert.Assert(t, s.checkFirewall(httptest.NewRecorder(), firewallRequest("203.0.113.2"), site))
+
+	site.Firewall = security.Firewall{RequestsPerSecond: 0.001, Burst: 1}
+	assert.Assert(t, s.checkFirewall(httptest.NewRecorder(), firewallRequest("203.0.113.3"), site))
+	assert.Assert(t, !s.checkFirewall(httptest.NewRecorder(), firewallRequest("203.0.113.3"), site))
+}
@@ -0,0 +1,300 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chrollo-lucider-12/proxy/wasm"
+	"github.com/chrollo-lucifer-12/shared/db"
+	"github.com/chrollo-lucifer-12/shared/functions"
+	"github.com/chrollo-lucifer-12/shared/secrets"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+const (
+	maxFunctionModuleBytes = 32 << 20
+	maxFunctionEnvs        = 1024
+	functionLogStreamLen   = 1000
+	functionLogQueueLen    = 1024
+	functionLogBatchSize   = 200
+	functionLogFlushEvery  = 2 * time.Second
+)
+
+var hopHeaders = []string{"Connection", "Content-Length", "Keep-Alive", "Transfer-Encoding", "Upgrade"}
+
+type functionCall struct {
+	deploymentID uuid.UUID
+	method       string
+	route        string
+	status       int
+	duration     time.Duration
+	logs         []string
+	err          error
+}
+
+type functionEnvs struct {
+	mu   sync.Mutex
+	envs map[uuid.UUID]map[string]string
+}
+
+func (s *ServerClient) functionEnv(ctx context.Context, deploymentID uuid.UUID) (map[string]string, error) {
+	s.envs.mu.Lock()
+	env, ok := s.envs.envs[deploymentID]
+	s.envs.mu.Unlock()
+	if ok {
+		return env, nil
+	}
+
+	projectID, encrypted, err := s.db.GetDeploymentEnv(ctx, deploymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	env = map[string]string{}
+	if len(encrypted) > 0 {
+		if s.secrets == nil {
+			return nil, secrets.ErrNoKey
+		}
+		env, err = s.secrets.DecryptAll(encrypted, projectID.String())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.envs.mu.Lock()
+	if s.envs.envs == nil || len(s.envs.envs) >= maxFunctionEnvs {
+		s.envs.envs = make(map[uuid.UUID]map[string]string)
+	}
+	s.envs.envs[deploymentID] = env
+	s.envs.mu.Unlock()
+
+	return env, nil
+}
+
+func (s *ServerClient) loadFunction(key string) func(context.Context) ([]byte, error) {
+	return func(ctx context.Context) ([]byte, error) {
+		object, err := s.storage.GetObject(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		defer object.Body.Close()
+
+		data, err := io.ReadAll(io.LimitReader(object.Body, maxFunctionModuleBytes+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > maxFunctionModuleBytes {
+			return nil, fmt.Errorf("function module %s is too large", key)
+		}
+
+		return data, nil
+	}
+}
+
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if c > '~' || c <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, c) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *ServerClient) invokeFunction(w http.ResponseWriter, r *http.Request, site *Site, route string) int {
+	ctx := r.Context()
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.functions.MaxBodyBytes()))
+	if err != nil {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return http.StatusRequestEntityTooLarge
+	}
+
+	env, err := s.functionEnv(ctx, site.DeploymentID)
+	if err != nil {
+		log.Printf("Failed to load environment for deployment %s: %v", site.DeploymentID, err)
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+		return http.StatusBadGateway
+	}
+
+	key := functions.ObjectKey(site.Prefix, route)
+
+	result, err := s.functions.Invoke(ctx, wasm.Invocation{
+		Key:  key,
+		Load: s.loadFunction(key),
+		Env:  env,
+		Request: functions.Request{
+			Method:  r.Method,
+			Path:    r.URL.Path,
+			Query:   r.URL.RawQuery,
+			Headers: r.Header.Clone(),
+			Body:    body,
+		},
+	})
+
+	var status int
+	switch {
+	case err == nil:
+		status = result.Response.Status
+	case errors.Is(err, wasm.ErrBusy):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, wasm.ErrTimeout):
+		status = http.StatusGatewayTimeout
+	case errors.Is(err, wasm.ErrRequestTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case ctx.Err() != nil:
+		status = http.StatusRequestTimeout
+	default:
+		status = http.StatusBadGateway
+		log.Printf("Function %s%s failed: %v", site.SubDomain, route, err)
+	}
+
+	if result != nil {
+		s.queueFunctionLogs(functionCall{
+			deploymentID: site.DeploymentID,
+			method:       r.Method,
+			route:        route,
+			status:       status,
+			duration:     result.Duration,
+			logs:         result.Logs,
+			err:          err,
+		})
+	}
+
+	if ctx.Err() != nil {
+		return status
+	}
+
+	if err != nil {
+		w.Header().Set("Cache-Control", "no-store")
+		if status == http.StatusServiceUnavailable {
+			w.Header().Set("Retry-After", "1")
+		}
+		http.Error(w, strings.ToLower(http.StatusText(status)), status)
+		return status
+	}
+
+	h := w.Header()
+	for name, values := range result.Response.Headers {
+		if !validHeaderName(name) {
+			continue
+		}
+		for _, value := range values {
+			h.Add(name, value)
+		}
+	}
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+	if h.Get("Cache-Control") == "" {
+		h.Set("Cache-Control", "no-store")
+	}
+
+	w.WriteHeader(status)
+	_, _ = w.Write(result.Response.Body)
+
+	return status
+}
+
+func (s *ServerClient) queueFunctionLogs(call functionCall) {
+	select {
+	case s.functionLogs <- call:
+	default:
+		log.Printf("Function log queue is full, dropping logs for %s", call.route)
+	}
+}
+
+func (s *ServerClient) recordFunctionLogs(ctx context.Context) {
+	ticker := time.NewTicker(functionLogFlushEvery)
+	defer ticker.Stop()
+
+	var events []db.LogEvent
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.saveFunctionLogs(events)
+			return
+		case call := <-s.functionLogs:
+			events = append(events, s.streamFunctionLogs(ctx, call)...)
+			if len(events) >= functionLogBatchSize {
+				s.saveFunctionLogs(events)
+				events = nil
+			}
+		case <-ticker.C:
+			s.saveFunctionLogs(events)
+			events = nil
+		}
+	}
+}
+
+func (s *ServerClient) streamFunctionLogs(ctx context.Context, call functionCall) []db.LogEvent {
+	summary := fmt.Sprintf("[function] %s %s %d in %dms", call.method, call.route, call.status, call.duration.Milliseconds())
+	if call.err != nil {
+		summary += ": " + call.err.Error()
+	}
+
+	lines := append([]string{summary}, call.logs...)
+
+	streamName := "deployment_logs:" + call.deploymentID.String()
+
+	for i, line := range lines {
+		if i > 0 {
+			lines[i] = "[function " + call.route + "] " + line
+		}
+
+		if _, err := s.rd.StreamAdd(ctx, streamName, map[string]interface{}{"message": lines[i]}); err != nil {
+			log.Printf("Failed to stream function log: %v", err)
+		}
+	}
+
+	if err := s.rd.StreamTrim(ctx, streamName, functionLogStreamLen); err != nil {
+		log.Printf("Failed to trim function log stream: %v", err)
+	}
+
+	if call.err == nil && len(call.logs) == 0 {
+		return nil
+	}
+
+	metadata, _ := json.Marshal(map[string]any{
+		"source":      "function",
+		"route":       call.route,
+		"status":      call.status,
+		"duration_ms": call.duration.Milliseconds(),
+	})
+
+	events := make([]db.LogEvent, 0, len(lines))
+	for _, line := range lines {
+		events = append(events, db.LogEvent{
+			DeploymentID: call.deploymentID,
+			Log:          line,
+			Metadata:     datatypes.JSON(metadata),
+		})
+	}
+
+	return events
+}
+
+func (s *ServerClient) saveFunctionLogs(events []db.LogEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := s.db.CreateLogEvents(ctx, &events); err != nil {
+		log.Printf("Failed to save function logs: %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	gopath "path"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/chrollo-lucider-12/proxy/cache"
+	"github.com/chrollo-lucider-12/proxy/images"
+	"github.com/chrollo-lucifer-12/shared/storage"
+)
+
+const (
+	imagePath           = "/_image"
+	defaultImageQuality = 75
+)
+
+var imageQualities = []int{25, 50, 75, 90, 100}
+
+var defaultImageWidths = []int{16, 32, 48, 64, 96, 128, 256, 384, 640, 750, 828, 1080, 1200, 1920, 2048, 3840}
+
+type ImageConfig struct {
+	Widths         []int
+	MaxSourceBytes int64
+	MaxPixels      int
+	Concurrency    int
+}
+
+func (c *ImageConfig) normalize() {
+	if len(c.Widths) == 0 {
+		c.Widths = defaultImageWidths
+	}
+	if c.MaxSourceBytes <= 0 {
+		c.MaxSourceBytes = 16 << 20
+	}
+	if c.MaxPixels <= 0 {
+		c.MaxPixels = 40_000_000
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = runtime.NumCPU()
+	}
+}
+
+func ParseImageWidths(value string) []int {
+	var widths []int
+	for _, field := range strings.Split(value, ",") {
+		width, err := strconv.Atoi(strings.TrimSpace(field))
+		if err == nil && width > 0 {
+			widths = append(widths, width)
+		}
+	}
+	return widths
+}
+
+type imageRequest struct {
+	source  string
+	width   int
+	quality int
+	webp    bool
+}
+
+func (s *ServerClient) parseImageRequest(r *http.Request) (imageRequest, error) {
+	query := r.URL.Query()
+
+	source := query.Get("url")
+	if !strings.HasPrefix(source, "/") || strings.HasPrefix(source, "//") {
+		return imageRequest{}, fmt.Errorf("url must be a path on this site")
+	}
+	source = gopath.Clean(source)
+
+	width, err := strconv.Atoi(query.Get("w"))
+	if err != nil || !slices.Contains(s.cfg.Images.Widths, width) {
+		return imageRequest{}, fmt.Errorf("w must be one of %v", s.cfg.Images.Widths)
+	}
+
+	req := imageRequest{
+		source:  source,
+		width:   width,
+		quality: defaultImageQuality,
+		webp:    images.AcceptsWebP(r.Header.Get("Accept")),
+	}
+
+	if raw := query.Get("q"); raw != "" {
+		quality, err := strconv.Atoi(raw)
+		if err != nil || quality < 1 || quality > 100 {
+			return imageRequest{}, fmt.Errorf("q must be between 1 and 100")
+		}
+		req.quality = snapQuality(quality)
+	}
+
+	if !req.lossy() {
+		req.quality = defaultImageQuality
+	}
+
+	return req, nil
+}
+
+func snapQuality(quality int) int {
+	for _, bucket := range imageQualities {
+		if quality <= bucket {
+			return bucket
+		}
+	}
+	return imageQualities[len(imageQualities)-1]
+}
+
+func (req imageRequest) lossy() bool {
+	switch strings.ToLower(gopath.Ext(req.source)) {
+	case ".jpg", ".jpeg":
+		return true
+	}
+	return false
+}
+
+func (req imageRequest) cacheKey(prefix string) string {
+	variant := "default"
+	if req.webp {
+		variant = "webp"
+	}
+	if req.lossy() {
+		return fmt.Sprintf("%s%s/%s/w%d/q%d%s", prefix, imagePath, variant, req.width, req.quality, req.source)
+	}
+	return fmt.Sprintf("%s%s/%s/w%d%s", prefix, imagePath, variant, req.width, req.source)
+}
+
+func (s *ServerClient) readImageSource(r *http.Request, key string) ([]byte, *storage.Object, error) {
+	object, err := s.storage.GetObject(r.Context(), key)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer object.Body.Close()
+
+	if object.ContentLength > s.cfg.Images.MaxSourceBytes {
+		return nil, nil, images.ErrTooLarge
+	}
+
+	data, err := io.ReadAll(io.LimitReader(object.Body, s.cfg.Images.MaxSourceBytes+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if int64(len(data)) > s.cfg.Images.MaxSourceBytes {
+		return nil, nil, images.ErrTooLarge
+	}
+
+	return data, object, nil
+}
+
+func (s *ServerClient) serveImage(w http.ResponseWriter, r *http.Request, site *Site) int {
+	ctx := r.Context()
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return http.StatusMethodNotAllowed
+	}
+
+	req, err := s.parseImageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return http.StatusBadRequest
+	}
+
+	cacheKey := req.cacheKey(site.Prefix)
+
+	if entry, ok := s.cache.Get(ctx, cacheKey); ok {
+		return serveCached(w, r, entry)
+	}
+
+	select {
+	case s.imageSlots <- struct{}{}:
+		defer func() { <-s.imageSlots }()
+	case <-ctx.Done():
+		return http.StatusServiceUnavailable
+	}
+
+	data, object, err := s.readImageSource(r, site.Prefix+req.source)
+	if err != nil {
+		switch {
+		case storage.IsNotFound(err):
+			http.NotFound(w, r)
+			return http.StatusNotFound
+		case errors.Is(err, images.ErrTooLarge):
+			http.Error(w, "source image is too large", http.StatusBadRequest)
+			return http.StatusBadRequest
+		}
+		log.Printf("Failed to read image %s%s: %v", site.Prefix, req.source, err)
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+		return http.StatusBadGateway
+	}
+
+	_, sourceFormat, err := images.Inspect(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return http.StatusBadRequest
+	}
+
+	format := images.Negotiate(req.webp, sourceFormat)
+
+	body, err := images.Transform(data, images.Options{
+		Width:     req.width,
+		Quality:   req.quality,
+		Format:    format,
+		MaxPixels: s.cfg.Images.MaxPixels,
+	})
+	if err != nil {
+		if errors.Is(err, images.ErrTooLarge) || errors.Is(err, images.ErrUnsupported) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return http.StatusBadRequest
+		}
+		log.Printf("Failed to optimize image %s%s: %v", site.Prefix, req.source, err)
+		http.Error(w, "failed to optimize image", http.StatusInternalServerError)
+		return http.StatusInternalServerError
+	}
+
+	etag := sha256.Sum256([]byte(object.ETag + cacheKey))
+
+	header := http.Header{}
+	header.Set("Content-Type", images.ContentType(format))
+	header.Set("Vary", "Accept")
+	header.Set("ETag", `"`+hex.EncodeToString(etag[:16])+`"`)
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	if !object.LastModified.IsZero() {
+		header.Set("Last-Modified", object.LastModified.UTC().Format(http.TimeFormat))
+	}
+	if value := site.CachePolicy.Value(false, object.Fingerprinted); value != "" {
+		header.Set("Cache-Control", value)
+	}
+
+	entry := &cache.Entry{
+		Meta: cache.Meta{
+			Status: http.StatusOK,
+			Header: header,
+		},
+		Body: body,
+	}
+	s.cache.Set(ctx, cacheKey, entry)
+
+	return serveCached(w, r, entry)
+}
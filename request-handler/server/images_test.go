@@ -0,0 +1,61 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func newImageServer() *ServerClient {
+	cfg := Config{}
+	cfg.Images.normalize()
+	return &ServerClient{cfg: cfg}
+}
+
+func TestParseImageRequest(t *testing.T) {
+	s := newImageServer()
+
+	r := httptest.NewRequest("GET", "/_image?url=/photos/../cat.jpg&w=640&q=80", nil)
+	r.Header.Set("Accept", "image/webp")
+
+	req, err := s.parseImageRequest(r)
+	assert.NilError(t, err)
+	assert.Equal(t, req.source, "/cat.jpg")
+	assert.Equal(t, req.width, 640)
+	assert.Equal(t, req.quality, 90)
+	assert.Assert(t, req.webp)
+
+	for _, target := range []string{
+		"/_image?url=https://evil.example/cat.jpg&w=640",
+		"/_image?url=//evil.example/cat.jpg&w=640",
+		"/_image?url=/cat.jpg&w=641",
+		"/_image?url=/cat.jpg&w=640&q=0",
+		"/_image?url=/cat.jpg&w=640&q=abc",
+	} {
+		_, err := s.parseImageRequest(httptest.NewRequest("GET", target, nil))
+		assert.Assert(t, err != nil, target)
+	}
+}
+
+func TestSnapQuality(t *testing.T) {
+	for quality, want := range map[int]int{1: 25, 25: 25, 26: 50, 74: 75, 76: 90, 91: 100, 100: 100} {
+		assert.Equal(t, snapQuality(quality), want, quality)
+	}
+}
+
+func TestImageCacheKey(t *testing.T) {
+	s := newImageServer()
+
+	key := func(target string) string {
+		req, err := s.parseImageRequest(httptest.NewRequest("GET", target, nil))
+		assert.NilError(t, err)
+		return req.cacheKey("blog3")
+	}
+
+	assert.Equal(t, key("/_image?url=/cat.jpg&w=640&q=60"), "blog3/_image/default/w640/q75/cat.jpg")
+	assert.Equal(t, key("/_image?url=/cat.jpg&w=640&q=61"), key("/_image?url=/cat.jpg&w=640&q=70"))
+
+	assert.Equal(t, key("/_image?url=/logo.png&w=640&q=30"), "blog3/_image/default/w640/logo.png")
+	assert.Equal(t, key("/_image?url=/logo.png&w=640&q=30"), key("/_image?url=/logo.png&w=640&q=90"))
+}
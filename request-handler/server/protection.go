@@ -0,0 +1,184 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chrollo-lucifer-12/shared/routing"
+	"github.com/chrollo-lucifer-12/shared/security"
+	"github.com/chrollo-lucifer-12/shared/utils"
+)
+
+const (
+	protectionCookie      = "__protection"
+	protectionCookieTTL   = 24 * time.Hour
+	protectionPasswordKey = "_protection_password"
+	maxVerifiedPasswords  = 4096
+)
+
+var loginPage = template.Must(template.New("login").Parse(`<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Protected deployment</title>
+<style>
+body{font-family:system-ui,sans-serif;display:flex;align-items:center;justify-content:center;min-height:100vh;margin:0;background:#fafafa}
+form{background:#fff;padding:2rem;border:1px solid #eaeaea;border-radius:8px;width:18rem}
+input,button{width:100%;box-sizing:border-box;padding:.6rem;margin-top:.75rem;font-size:1rem}
+p.error{color:#e00}
+</style>
+</head>
+<body>
+<form method="POST">
+<h1>Password required</h1>
+{{if .Failed}}<p class="error">Incorrect password.</p>{{end}}
+<input type="password" name="` + protectionPasswordKey + `" placeholder="Password" autofocus required>
+<button type="submit">Continue</button>
+</form>
+</body>
+</html>
+`))
+
+type verifiedPasswords struct {
+	mu     sync.Mutex
+	hashes map[string]struct{}
+}
+
+func (v *verifiedPasswords) check(password, hash string) bool {
+	sum := sha256.Sum256([]byte(password + "\x00" + hash))
+	key := string(sum[:])
+
+	v.mu.Lock()
+	_, ok := v.hashes[key]
+	v.mu.Unlock()
+	if ok {
+		return true
+	}
+
+	if !utils.CheckPassword(password, hash) {
+		return false
+	}
+
+	v.mu.Lock()
+	if v.hashes == nil || len(v.hashes) >= maxVerifiedPasswords {
+		v.hashes = make(map[string]struct{})
+	}
+	v.hashes[key] = struct{}{}
+	v.mu.Unlock()
+
+	return true
+}
+
+func (s *ServerClient) signProtectionCookie(protection security.Protection, expires int64) string {
+	mac := hmac.New(sha256.New, s.protectionSecret)
+	mac.Write([]byte(strconv.FormatInt(expires, 10) + "|" + protection.PasswordHash))
+	return strconv.FormatInt(expires, 10) + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *ServerClient) validProtectionCookie(r *http.Request, protection security.Protection) bool {
+	cookie, err := r.Cookie(protectionCookie)
+	if err != nil {
+		return false
+	}
+
+	rawExpires, _, ok := strings.Cut(cookie.Value, ".")
+	if !ok {
+		return false
+	}
+
+	expires, err := strconv.ParseInt(rawExpires, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+
+	return hmac.Equal([]byte(cookie.Value), []byte(s.signProtectionCookie(protection, expires)))
+}
+
+func (s *ServerClient) setProtectionCookie(w http.ResponseWriter, r *http.Request, protection security.Protection) {
+	expires := time.Now().Add(protectionCookieTTL)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     protectionCookie,
+		Value:    s.signProtectionCookie(protection, expires.Unix()),
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   s.secureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func protectionHeaders(site *Site) []routing.Header {
+	if !site.Protection.Enabled() {
+		return nil
+	}
+	return []routing.Header{{Key: "Cache-Control", Value: "private, no-store"}}
+}
+
+func bypassToken(r *http.Request) string {
+	if token := r.Header.Get(security.BypassTokenParam); token != "" {
+		return token
+	}
+	return r.URL.Query().Get(security.BypassTokenParam)
+}
+
+func (s *ServerClient) authorize(w http.ResponseWriter, r *http.Request, site *Site) (int, bool) {
+	protection := site.Protection
+	if !protection.Enabled() {
+		return 0, true
+	}
+
+	if protection.CheckBypassToken(bypassToken(r)) {
+		return 0, true
+	}
+
+	switch protection.Mode {
+	case security.ProtectionBasic:
+		username, password, ok := r.BasicAuth()
+		if ok && subtle.ConstantTimeCompare([]byte(username), []byte(protection.Username)) == 1 &&
+			s.verified.check(password, protection.PasswordHash) {
+			return 0, true
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("WWW-Authenticate", `Basic realm="`+site.SubDomain+`", charset="UTF-8"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return http.StatusUnauthorized, false
+
+	case security.ProtectionPassword:
+		if s.validProtectionCookie(r, protection) {
+			return 0, true
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+
+		failed := false
+		if r.Method == http.MethodPost {
+			r.Body = http.MaxBytesReader(w, r.Body, 4096)
+			password := r.PostFormValue(protectionPasswordKey)
+			if password != "" && s.verified.check(password, protection.PasswordHash) {
+				s.setProtectionCookie(w, r, protection)
+				http.Redirect(w, r, r.URL.RequestURI(), http.StatusSeeOther)
+				return http.StatusSeeOther, false
+			}
+			failed = true
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusUnauthorized)
+		loginPage.Execute(w, struct{ Failed bool }{failed})
+		return http.StatusUnauthorized, false
+	}
+
+	http.Error(w, "forbidden", http.StatusForbidden)
+	return http.StatusForbidden, false
+}
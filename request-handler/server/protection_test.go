@@ -0,0 +1,180 @@
+package server
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrollo-lucifer-12/shared/security"
+	"github.com/chrollo-lucifer-12/shared/utils"
+	"gotest.tools/v3/assert"
+)
+
+func protectedSite(t *testing.T, mode string) (*Site, security.Protection) {
+	hash, err := utils.HashPassword("correct horse")
+	assert.NilError(t, err)
+
+	protection := security.Protection{
+		Mode:            mode,
+		Username:        "admin",
+		PasswordHash:    hash,
+		BypassTokenHash: security.HashToken("bypass"),
+	}
+
+	site := &Site{SubDomain: "blog"}
+	site.Protection = protection
+	return site, protection
+}
+
+func newProtectionServer(trustProxy bool) *ServerClient {
+	return &ServerClient{
+		cfg:              Config{TrustProxy: trustProxy},
+		protectionSecret: []byte("secret"),
+	}
+}
+
+func requestWithCookie(cookie *http.Cookie) *http.Request {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(cookie)
+	return r
+}
+
+func TestProtectionCookie(t *testing.T) {
+	s := newProtectionServer(false)
+	_, protection := protectedSite(t, security.ProtectionPassword)
+
+	w := httptest.NewRecorder()
+	s.setProtectionCookie(w, httptest.NewRequest("GET", "/", nil), protection)
+
+	cookies := w.Result().Cookies()
+	assert.Equal(t, len(cookies), 1)
+	cookie := cookies[0]
+	assert.Equal(t, cookie.Name, protectionCookie)
+	assert.Assert(t, cookie.HttpOnly)
+
+	assert.Assert(t, s.validProtectionCookie(requestWithCookie(cookie), protection))
+
+	changed := protection
+	changed.PasswordHash = "rotated"
+	assert.Assert(t, !s.validProtectionCookie(requestWithCookie(cookie), changed))
+
+	other := newProtectionServer(false)
+	other.protectionSecret = []byte("other")
+	assert.Assert(t, !other.validProtectionCookie(requestWithCookie(cookie), protection))
+
+	expired := *cookie
+	expired.Value = s.signProtectionCookie(protection, time.Now().Add(-time.Minute).Unix())
+	assert.Assert(t, !s.validProtectionCookie(requestWithCookie(&expired), protection))
+
+	tampered := *cookie
+	tampered.Value = "9999999999." + strings.Repeat("0", 64)
+	assert.Assert(t, !s.validProtectionCookie(requestWithCookie(&tampered), protection))
+}
+
+func TestProtectionCookieSecureFlag(t *testing.T) {
+	_, protection := protectedSite(t, security.ProtectionPassword)
+
+	cases := []struct {
+		trustProxy bool
+		tls        bool
+		proto      string
+		secure     bool
+	}{
+		{false, false, "", false},
+		{false, false, "https", false},
+		{true, false, "https", true},
+		{true, false, "http", false},
+		{false, true, "", true},
+	}
+
+	for _, c := range cases {
+		s := newProtectionServer(c.trustProxy)
+		r := httptest.NewRequest("GET", "/", nil)
+		if c.tls {
+			r.TLS = &tls.ConnectionState{}
+		}
+		if c.proto != "" {
+			r.Header.Set("X-Forwarded-Proto", c.proto)
+		}
+
+		w := httptest.NewRecorder()
+		s.setProtectionCookie(w, r, protection)
+		assert.Equal(t, w.Result().Cookies()[0].Secure, c.secure)
+	}
+}
+
+func TestAuthorizeBasic(t *testing.T) {
+	s := newProtectionServer(false)
+	site, _ := protectedSite(t, security.ProtectionBasic)
+
+	w := httptest.NewRecorder()
+	status, ok := s.authorize(w, httptest.NewRequest("GET", "/", nil), site)
+	assert.Assert(t, !ok)
+	assert.Equal(t, status, http.StatusUnauthorized)
+	assert.Assert(t, strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Basic"))
+	assert.Equal(t, w.Header().Get("Cache-Control"), "no-store")
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.SetBasicAuth("admin", "wrong")
+	_, ok = s.authorize(httptest.NewRecorder(), r, site)
+	assert.Assert(t, !ok)
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.SetBasicAuth("admin", "correct horse")
+	_, ok = s.authorize(httptest.NewRecorder(), r, site)
+	assert.Assert(t, ok)
+
+	r = httptest.NewRequest("GET", "/?"+security.BypassTokenParam+"=bypass", nil)
+	_, ok = s.authorize(httptest.NewRecorder(), r, site)
+	assert.Assert(t, ok)
+}
+
+func TestAuthorizePassword(t *testing.T) {
+	s := newProtectionServer(false)
+	site, _ := protectedSite(t, security.ProtectionPassword)
+
+	w := httptest.NewRecorder()
+	status, ok := s.authorize(w, httptest.NewRequest("GET", "/docs", nil), site)
+	assert.Assert(t, !ok)
+	assert.Equal(t, status, http.StatusUnauthorized)
+	assert.Assert(t, strings.Contains(w.Body.String(), protectionPasswordKey))
+
+	form := url.Values{protectionPasswordKey: {"wrong"}}
+	r := httptest.NewRequest("POST", "/docs", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	_, ok = s.authorize(w, r, site)
+	assert.Assert(t, !ok)
+	assert.Assert(t, strings.Contains(w.Body.String(), "Incorrect password"))
+
+	form = url.Values{protectionPasswordKey: {"correct horse"}}
+	r = httptest.NewRequest("POST", "/docs", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	status, ok = s.authorize(w, r, site)
+	assert.Assert(t, !ok)
+	assert.Equal(t, status, http.StatusSeeOther)
+	assert.Equal(t, w.Header().Get("Location"), "/docs")
+
+	cookie := w.Result().Cookies()[0]
+	_, ok = s.authorize(httptest.NewRecorder(), requestWithCookie(cookie), site)
+	assert.Assert(t, ok)
+}
+
+func TestProtectedResponsesAreNotCacheable(t *testing.T) {
+	site, _ := protectedSite(t, security.ProtectionPassword)
+
+	rec := httptest.NewRecorder()
+	w := &headerWriter{ResponseWriter: rec, headers: protectionHeaders(site)}
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.WriteHeader(http.StatusOK)
+
+	assert.Equal(t, rec.Header().Get("Cache-Control"), "private, no-store")
+
+	site.Protection = security.Protection{Mode: security.ProtectionNone}
+	assert.Equal(t, len(protectionHeaders(site)), 0)
+}
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+const maxRanges = 16
+
+var (
+	errNoRange            = errors.New("range: not applicable")
+	errUnsatisfiableRange = errors.New("range: not satisfiable")
+	errRangeOpen          = errors.New("range: failed to open part")
+)
+
+type byteRange struct {
+	start  int64
+	length int64
+}
+
+func (r byteRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, size)
+}
+
+func parseRange(header string, size int64) ([]byteRange, error) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return nil, errNoRange
+	}
+
+	var ranges []byteRange
+	noOverlap := false
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		first, last, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, errNoRange
+		}
+		first, last = strings.TrimSpace(first), strings.TrimSpace(last)
+
+		var rng byteRange
+
+		if first == "" {
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n < 0 {
+				return nil, errNoRange
+			}
+			if n == 0 || size == 0 {
+				noOverlap = true
+				continue
+			}
+			if n > size {
+				n = size
+			}
+			rng = byteRange{start: size - n, length: n}
+		} else {
+			start, err := strconv.ParseInt(first, 10, 64)
+			if err != nil || start < 0 {
+				return nil, errNoRange
+			}
+			if start >= size {
+				noOverlap = true
+				continue
+			}
+
+			end := size - 1
+			if last != "" {
+				end, err = strconv.ParseInt(last, 10, 64)
+				if err != nil || end < start {
+					return nil, errNoRange
+				}
+				if end >= size {
+					end = size - 1
+				}
+			}
+			rng = byteRange{start: start, length: end - start + 1}
+		}
+
+		ranges = append(ranges, rng)
+	}
+
+	if len(ranges) == 0 {
+		if noOverlap {
+			return nil, errUnsatisfiableRange
+		}
+		return nil, errNoRange
+	}
+
+	if len(ranges) > maxRanges {
+		return nil, errNoRange
+	}
+
+	return ranges, nil
+}
+
+func ifRangeMatches(ifRange string, h http.Header) bool {
+	if strings.HasPrefix(ifRange, `"`) {
+		etag := h.Get("ETag")
+		return etag != "" && !strings.HasPrefix(etag, "W/") && ifRange == etag
+	}
+
+	if strings.HasPrefix(ifRange, "W/") {
+		return false
+	}
+
+	since, err := http.ParseTime(ifRange)
+	if err != nil {
+		return false
+	}
+
+	modified, err := http.ParseTime(h.Get("Last-Modified"))
+	return err == nil && modified.Equal(since)
+}
+
+func requestRanges(r *http.Request, h http.Header, size int64) ([]byteRange, error) {
+	header := r.Header.Get("Range")
+	if header == "" || r.Method != http.MethodGet {
+		return nil, errNoRange
+	}
+
+	if ifRange := r.Header.Get("If-Range"); ifRange != "" && !ifRangeMatches(ifRange, h) {
+		return nil, errNoRange
+	}
+
+	return parseRange(header, size)
+}
+
+func writeUnsatisfiable(w http.ResponseWriter, size int64) {
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+	http.Error(w, "requested range not satisfiable", http.StatusRequestedRangeNotSatisfiable)
+}
+
+func writeRanges(w http.ResponseWriter, ranges []byteRange, size int64, open func(byteRange) (io.ReadCloser, error)) error {
+	h := w.Header()
+
+	if len(ranges) == 1 {
+		rng := ranges[0]
+
+		body, err := open(rng)
+		if err != nil {
+			return errRangeOpen
+		}
+		defer body.Close()
+
+		h.Set("Content-Range", rng.contentRange(size))
+		h.Set("Content-Length", strconv.FormatInt(rng.length, 10))
+		w.WriteHeader(http.StatusPartialContent)
+
+		_, err = copyN(w, body, rng.length)
+		return err
+	}
+
+	contentType := h.Get("Content-Type")
+	mw := multipart.NewWriter(w)
+
+	h.Del("Content-Length")
+	h.Set("Content-Type", "multipart/byteranges; boundary="+mw.Boundary())
+	w.WriteHeader(http.StatusPartialContent)
+
+	for _, rng := range ranges {
+		partHeader := textproto.MIMEHeader{}
+		partHeader.Set("Content-Range", rng.contentRange(size))
+		if contentType != "" {
+			partHeader.Set("Content-Type", contentType)
+		}
+
+		part, err := mw.CreatePart(partHeader)
+		if err != nil {
+			return err
+		}
+
+		body, err := open(rng)
+		if err != nil {
+			return err
+		}
+
+		_, err = copyN(part, body, rng.length)
+		body.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return mw.Close()
+}
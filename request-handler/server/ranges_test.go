@@ -0,0 +1,141 @@
+package server
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseRange(t *testing.T) {
+	cases := []struct {
+		header string
+		want   []byteRange
+		err    error
+	}{
+		{"bytes=0-4", []byteRange{{0, 5}}, nil},
+		{"bytes=5-", []byteRange{{5, 5}}, nil},
+		{"bytes=-3", []byteRange{{7, 3}}, nil},
+		{"bytes=-30", []byteRange{{0, 10}}, nil},
+		{"bytes=8-20", []byteRange{{8, 2}}, nil},
+		{"bytes=0-1, 4-5", []byteRange{{0, 2}, {4, 2}}, nil},
+		{"bytes=10-", nil, errUnsatisfiableRange},
+		{"bytes=-0", nil, errUnsatisfiableRange},
+		{"bytes=4-2", nil, errNoRange},
+		{"bytes=a-b", nil, errNoRange},
+		{"items=0-1", nil, errNoRange},
+		{"bytes=" + strings.Repeat("0-0,", maxRanges+1), nil, errNoRange},
+	}
+
+	for _, c := range cases {
+		got, err := parseRange(c.header, 10)
+		assert.Equal(t, err, c.err, c.header)
+		assert.DeepEqual(t, rangePairs(got), rangePairs(c.want))
+	}
+}
+
+func rangePairs(ranges []byteRange) [][2]int64 {
+	var pairs [][2]int64
+	for _, rng := range ranges {
+		pairs = append(pairs, [2]int64{rng.start, rng.length})
+	}
+	return pairs
+}
+
+func TestRequestRangesIfRange(t *testing.T) {
+	h := http.Header{}
+	h.Set("ETag", `"v1"`)
+	h.Set("Last-Modified", "Wed, 01 May 2024 12:00:00 GMT")
+
+	cases := []struct {
+		ifRange string
+		ok      bool
+	}{
+		{"", true},
+		{`"v1"`, true},
+		{`"v0"`, false},
+		{`W/"v1"`, false},
+		{"Wed, 01 May 2024 12:00:00 GMT", true},
+		{"Wed, 01 May 2024 11:00:00 GMT", false},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Range", "bytes=0-1")
+		if c.ifRange != "" {
+			r.Header.Set("If-Range", c.ifRange)
+		}
+
+		_, err := requestRanges(r, h, 10)
+		assert.Equal(t, err == nil, c.ok, c.ifRange)
+	}
+
+	r := httptest.NewRequest("HEAD", "/", nil)
+	r.Header.Set("Range", "bytes=0-1")
+	_, err := requestRanges(r, h, 10)
+	assert.Equal(t, err, errNoRange)
+}
+
+func openString(body string) func(byteRange) (io.ReadCloser, error) {
+	return func(rng byteRange) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(body[rng.start : rng.start+rng.length])), nil
+	}
+}
+
+func TestWriteSingleRange(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	err := writeRanges(w, []byteRange{{2, 3}}, 10, openString("0123456789"))
+	assert.NilError(t, err)
+
+	assert.Equal(t, w.Code, http.StatusPartialContent)
+	assert.Equal(t, w.Header().Get("Content-Range"), "bytes 2-4/10")
+	assert.Equal(t, w.Header().Get("Content-Length"), "3")
+	assert.Equal(t, w.Body.String(), "234")
+}
+
+func TestWriteMultipleRanges(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Type", "text/plain")
+
+	err := writeRanges(w, []byteRange{{0, 2}, {8, 2}}, 10, openString("0123456789"))
+	assert.NilError(t, err)
+	assert.Equal(t, w.Code, http.StatusPartialContent)
+
+	mediaType, params, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	assert.NilError(t, err)
+	assert.Equal(t, mediaType, "multipart/byteranges")
+
+	mr := multipart.NewReader(w.Body, params["boundary"])
+
+	var parts []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, part.Header.Get("Content-Type"), "text/plain")
+
+		body, err := io.ReadAll(part)
+		assert.NilError(t, err)
+		parts = append(parts, part.Header.Get("Content-Range")+" "+string(body))
+	}
+
+	assert.DeepEqual(t, parts, []string{"bytes 0-1/10 01", "bytes 8-9/10 89"})
+}
+
+func TestWriteUnsatisfiable(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Length", "10")
+
+	writeUnsatisfiable(w, 10)
+
+	assert.Equal(t, w.Code, http.StatusRequestedRangeNotSatisfiable)
+	assert.Equal(t, w.Header().Get("Content-Range"), "bytes */10")
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chrollo-lucifer-12/shared/utils"
+)
+
+const (
+	domainCacheTTL         = 10 * time.Minute
+	domainNegativeCacheTTL = time.Minute
+)
+
+func normalizeHost(hostport string) string {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+func normalizePath(path string) string {
+	if path == "" || path == "/" {
+		return "/index.html"
+	}
+	if strings.HasSuffix(path, "/") {
+		return path + "index.html"
+	}
+	return path
+}
+
+func (s *ServerClient) resolveSubdomain(ctx context.Context, r *http.Request) (string, string, bool) {
+	host := normalizeHost(r.Host)
+
+	if s.cfg.BaseDomain != "" && strings.HasSuffix(host, "."+s.cfg.BaseDomain) {
+		label := strings.TrimSuffix(host, "."+s.cfg.BaseDomain)
+		if label != "" && !strings.Contains(label, ".") {
+			return label, r.URL.Path, true
+		}
+	}
+
+	if host != "" && host != s.cfg.BaseDomain {
+		if subdomain, ok := s.lookupCustomDomain(ctx, host); ok {
+			return subdomain, r.URL.Path, true
+		}
+	}
+
+	if !s.cfg.PathRouting {
+		return "", "", false
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	if parts[0] == "" {
+		return "", "", false
+	}
+
+	path := "/"
+	if len(parts) > 1 {
+		path += parts[1]
+	}
+
+	return parts[0], path, true
+}
+
+func (s *ServerClient) lookupCustomDomain(ctx context.Context, host string) (string, bool) {
+	key := utils.GetDomainCacheKey(host)
+
+	if cached, err := s.rd.Get(ctx, key); err == nil {
+		return cached, cached != ""
+	}
+
+	project, err := s.db.GetProjectByCustomDomain(ctx, host)
+	if err != nil {
+		s.rd.Set(ctx, key, "", domainNegativeCacheTTL)
+		return "", false
+	}
+
+	s.rd.Set(ctx, key, project.SubDomain, domainCacheTTL)
+	return project.SubDomain, true
+}
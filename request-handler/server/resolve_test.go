@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chrollo-lucifer-12/shared/db"
+	"github.com/google/uuid"
+	"gotest.tools/v3/assert"
+)
+
+func TestResolveSubdomain(t *testing.T) {
+	s := &ServerClient{cfg: Config{BaseDomain: "example.com", PathRouting: true}}
+
+	cases := []struct {
+		host, target string
+		subdomain    string
+		path         string
+		ok           bool
+	}{
+		{"blog.example.com", "/about", "blog", "/about", true},
+		{"Blog.Example.com.:443", "/", "blog", "/", true},
+		{"example.com", "/blog/about", "blog", "/about", true},
+		{"example.com", "/blog", "blog", "/", true},
+		{"example.com", "/", "", "", false},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", c.target, nil)
+		r.Host = c.host
+
+		subdomain, path, ok := s.resolveSubdomain(context.Background(), r)
+		assert.Equal(t, ok, c.ok, c.host+c.target)
+		assert.Equal(t, subdomain, c.subdomain, c.host+c.target)
+		assert.Equal(t, path, c.path, c.host+c.target)
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	assert.Equal(t, normalizePath(""), "/index.html")
+	assert.Equal(t, normalizePath("/"), "/index.html")
+	assert.Equal(t, normalizePath("/docs/"), "/docs/index.html")
+	assert.Equal(t, normalizePath("/app.js"), "/app.js")
+}
+
+func TestNewSiteDeployment(t *testing.T) {
+	project := db.Project{SubDomain: "blog"}
+	deployment := db.Deployment{Sequence: 3}
+	deployment.ID = uuid.New()
+
+	site := newSiteDeployment(project, deployment)
+	assert.Equal(t, site.DeploymentID, deployment.ID)
+	assert.Equal(t, site.Prefix, "blog3")
+}
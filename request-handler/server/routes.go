@@ -0,0 +1,92 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/chrollo-lucifer-12/shared/routing"
+	"github.com/google/uuid"
+)
+
+const maxRouters = 1024
+
+type headerWriter struct {
+	http.ResponseWriter
+	headers     []routing.Header
+	wroteHeader bool
+}
+
+func (w *headerWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		h := w.Header()
+		for _, header := range w.headers {
+			if header.Value == "" {
+				h.Del(header.Key)
+				continue
+			}
+			h.Set(header.Key, header.Value)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *headerWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+type siteRouters struct {
+	mu      sync.Mutex
+	routers map[uuid.UUID]*routing.Router
+}
+
+func (s *ServerClient) router(site *Site) *routing.Router {
+	s.routers.mu.Lock()
+	cached, ok := s.routers.routers[site.DeploymentID]
+	s.routers.mu.Unlock()
+	if ok {
+		return cached
+	}
+
+	var cfg routing.Config
+	if len(site.Routes) > 0 {
+		if err := json.Unmarshal(site.Routes, &cfg); err != nil {
+			log.Printf("Failed to decode routes for deployment %s: %v", site.DeploymentID, err)
+		}
+	}
+
+	router, err := routing.NewRouter(&cfg)
+	if err != nil {
+		log.Printf("Invalid routes for deployment %s: %v", site.DeploymentID, err)
+		router, _ = routing.NewRouter(nil)
+	}
+
+	s.routers.mu.Lock()
+	if s.routers.routers == nil || len(s.routers.routers) >= maxRouters {
+		s.routers.routers = make(map[uuid.UUID]*routing.Router)
+	}
+	s.routers.routers[site.DeploymentID] = router
+	s.routers.mu.Unlock()
+
+	return router
+}
+
+func (s *ServerClient) applyRedirect(w http.ResponseWriter, r *http.Request, router *routing.Router, path string) (int, bool) {
+	location, status, ok := router.Redirect(path, r.URL.RawQuery)
+	if !ok {
+		return 0, false
+	}
+
+	if strings.HasPrefix(location, "/") && !strings.HasPrefix(location, "//") {
+		location = strings.TrimSuffix(r.URL.Path, path) + location
+	}
+
+	http.Redirect(w, r, location, status)
+	return status, true
+}
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/chrollo-lucider-12/proxy/cache"
+	"github.com/chrollo-lucifer-12/shared/storage"
+)
+
+func setContentHeaders(h http.Header, path string) {
+	switch {
+	case strings.HasSuffix(path, ".html"):
+		h.Set("Content-Type", "text/html; charset=utf-8")
+	case strings.HasSuffix(path, ".js"):
+		h.Set("Content-Type", "application/javascript")
+	case strings.HasSuffix(path, ".css"):
+		h.Set("Content-Type", "text/css")
+	case strings.HasSuffix(path, ".svg"):
+		h.Set("Content-Type", "image/svg+xml")
+	}
+
+	h.Set("Accept-Ranges", "bytes")
+}
+
+func setCacheControl(h http.Header, site *Site, path string, object *storage.Object) {
+	html := strings.HasSuffix(path, ".html") || strings.HasPrefix(object.ContentType, "text/html")
+	if value := site.CachePolicy.Value(html, object.Fingerprinted); value != "" {
+		h.Set("Cache-Control", value)
+	}
+}
+
+func (s *ServerClient) serve(w http.ResponseWriter, r *http.Request, site *Site, path string) int {
+	ctx := r.Context()
+
+	encodings := acceptedEncodings(r)
+	if r.Header.Get("Range") != "" {
+		encodings = nil
+	}
+
+	cacheKey := site.Prefix + path
+	if len(encodings) > 0 {
+		cacheKey += "#" + strings.Join(encodings, ",")
+	}
+
+	if entry, ok := s.cache.Get(ctx, cacheKey); ok {
+		return serveCached(w, r, entry)
+	}
+
+	if r.Method == http.MethodGet && r.Header.Get("Range") != "" {
+		if status, ok := s.serveStorageRange(w, r, site, path); ok {
+			return status
+		}
+	}
+
+	return s.serveStorage(w, r, site, path, cacheKey, encodings)
+}
+
+func serveCached(w http.ResponseWriter, r *http.Request, entry *cache.Entry) int {
+	h := w.Header()
+	for k, vv := range entry.Header {
+		for _, v := range vv {
+			h.Add(k, v)
+		}
+	}
+
+	if entry.Status != http.StatusOK {
+		w.WriteHeader(entry.Status)
+		_, _ = w.Write(entry.Body)
+		return entry.Status
+	}
+
+	if notModified(r, h) {
+		writeNotModified(w)
+		return http.StatusNotModified
+	}
+
+	ranges, err := requestRanges(r, h, int64(len(entry.Body)))
+	switch err {
+	case nil:
+		open := func(rng byteRange) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(entry.Body[rng.start : rng.start+rng.length])), nil
+		}
+		if err := writeRanges(w, ranges, int64(len(entry.Body)), open); err != nil {
+			log.Printf("Failed to write range response: %v", err)
+		}
+		return http.StatusPartialContent
+	case errUnsatisfiableRange:
+		writeUnsatisfiable(w, int64(len(entry.Body)))
+		return http.StatusRequestedRangeNotSatisfiable
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(entry.Body)
+	return http.StatusOK
+}
+
+func (s *ServerClient) serveStorageRange(w http.ResponseWriter, r *http.Request, site *Site, path string) (int, bool) {
+	ctx := r.Context()
+
+	object, resolvedPath, status, err := s.openObject(ctx, site, path, s.storage.StatObject)
+	if err != nil || status != http.StatusOK {
+		return 0, false
+	}
+
+	h := w.Header()
+	setContentHeaders(h, resolvedPath)
+	setCacheControl(h, site, resolvedPath, object)
+	setValidators(h, object)
+
+	if notModified(r, h) {
+		writeNotModified(w)
+		return http.StatusNotModified, true
+	}
+
+	ranges, err := requestRanges(r, h, object.ContentLength)
+	switch err {
+	case nil:
+	case errUnsatisfiableRange:
+		writeUnsatisfiable(w, object.ContentLength)
+		return http.StatusRequestedRangeNotSatisfiable, true
+	default:
+		return 0, false
+	}
+
+	key := site.Prefix + resolvedPath
+	open := func(rng byteRange) (io.ReadCloser, error) {
+		part, err := s.storage.GetObjectRange(ctx, key, rng.start, rng.length)
+		if err != nil {
+			return nil, err
+		}
+		return part.Body, nil
+	}
+
+	if err := writeRanges(w, ranges, object.ContentLength, open); err != nil {
+		log.Printf("Failed to write range response for %s: %v", key, err)
+		if err == errRangeOpen {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return http.StatusBadGateway, true
+		}
+	}
+
+	return http.StatusPartialContent, true
+}
+
+func (s *ServerClient) serveStorage(w http.ResponseWriter, r *http.Request, site *Site, path, cacheKey string, encodings []string) int {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	object, resolvedPath, status, err := s.openObject(ctx, site, path, s.encodedFetch(encodings))
+	if err != nil {
+		if storage.IsNotFound(err) {
+			http.NotFound(w, r)
+			return http.StatusNotFound
+		}
+		log.Printf("Failed to read object %s%s: %v", site.Prefix, path, err)
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+		return http.StatusBadGateway
+	}
+	defer object.Body.Close()
+
+	h := w.Header()
+	setContentHeaders(h, resolvedPath)
+	setCacheControl(h, site, resolvedPath, object)
+	setValidators(h, object)
+	gzipBody := setEncodingHeaders(h, resolvedPath, object, encodings)
+
+	if status == http.StatusOK && notModified(r, h) {
+		writeNotModified(w)
+		return http.StatusNotModified
+	}
+
+	header := h.Clone()
+	header.Del("Set-Cookie")
+	w.WriteHeader(status)
+
+	var dst io.Writer = w
+
+	var tee *cacheTee
+	if object.ContentLength < 0 || s.cache.Cacheable(object.ContentLength) {
+		tee = newCacheTee(object.ContentLength, s.cache.MaxObjectSize())
+		dst = io.MultiWriter(w, tee)
+	}
+
+	var gz *gzip.Writer
+	if gzipBody {
+		gz = gzipWriters.Get().(*gzip.Writer)
+		gz.Reset(dst)
+		defer gzipWriters.Put(gz)
+		dst = gz
+	}
+
+	_, err = copyBuffer(dst, object.Body)
+	if gz != nil {
+		if closeErr := gz.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Failed to write response for %s%s: %v", site.SubDomain, path, err)
+		}
+		return status
+	}
+
+	if tee == nil {
+		return status
+	}
+
+	body, ok := tee.Bytes()
+	if !ok {
+		s.cache.Skip()
+		return status
+	}
+
+	s.cache.Set(ctx, cacheKey, &cache.Entry{
+		Meta: cache.Meta{
+			Status: status,
+			Header: header,
+		},
+		Body: body,
+	})
+
+	return status
+}
@@ -2,19 +2,25 @@ package server
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/json"
-	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"os"
-	"strings"
 	"time"
 
+	"github.com/chrollo-lucider-12/proxy/cache"
+	"github.com/chrollo-lucider-12/proxy/wasm"
 	"github.com/chrollo-lucifer-12/shared/db"
+	"github.com/chrollo-lucifer-12/shared/functions"
 	"github.com/chrollo-lucifer-12/shared/queue"
 	"github.com/chrollo-lucifer-12/shared/redis"
+	"github.com/chrollo-lucifer-12/shared/routing"
+	"github.com/chrollo-lucifer-12/shared/secrets"
+	"github.com/chrollo-lucifer-12/shared/security"
 	"github.com/chrollo-lucifer-12/shared/storage"
+	"github.com/chrollo-lucifer-12/shared/utils"
+	"github.com/google/uuid"
 )
 
 const (
@@ -22,10 +28,19 @@ const (
 	BASE_PATH = "/storage/v1/object/public/builds"
 )
 
-type CacheEntry struct {
-	Status int         `json:"status"`
-	Header http.Header `json:"header"`
-	Body   string      `json:"body"`
+type Config struct {
+	BaseDomain  string
+	PathRouting bool
+	Cache       cache.Config
+	MetricsPort string
+
+	ProtectionSecret string
+	TrustProxy       bool
+	RateLimit        security.Firewall
+	TLS              TLSConfig
+	Functions        wasm.Config
+	Images           ImageConfig
+	EnvEncryptionKey string
 }
 
 type ServerClient struct {
@@ -33,18 +48,59 @@ type ServerClient struct {
 	storage *storage.S3Storage
 	rd      *redis.RedisClient
 	qu      *queue.QueueClient
+	cache   *cache.Cache
+	routers siteRouters
+	cfg     Config
+
+	protectionSecret []byte
+	verified         verifiedPasswords
+
+	functions    *wasm.Runtime
+	envs         functionEnvs
+	secrets      *secrets.Box
+	functionLogs chan functionCall
+
+	imageSlots chan struct{}
 }
 
-func NewServerClient(db *db.DB, storage *storage.S3Storage, rd *redis.RedisClient, qu *queue.QueueClient) *ServerClient {
+func NewServerClient(db *db.DB, storage *storage.S3Storage, rd *redis.RedisClient, qu *queue.QueueClient, cfg Config) *ServerClient {
+	cfg.BaseDomain = normalizeHost(cfg.BaseDomain)
+
+	if err := cfg.RateLimit.Normalize(); err != nil {
+		log.Printf("invalid default rate limit, disabling it: %v", err)
+		cfg.RateLimit = security.Firewall{}
+	}
+
+	protectionSecret := []byte(cfg.ProtectionSecret)
+	if len(protectionSecret) == 0 {
+		log.Println("PROTECTION_SECRET is not set, password cookies will not survive restarts")
+		protectionSecret = make([]byte, 32)
+		rand.Read(protectionSecret)
+	}
+
+	cfg.Images.normalize()
+
+	box, err := secrets.New(cfg.EnvEncryptionKey)
+	if err != nil {
+		log.Printf("environment variables are unavailable to functions: %v", err)
+	}
+
 	return &ServerClient{
 		db:      db,
 		storage: storage,
 		rd:      rd,
 		qu:      qu,
+		cache:   cache.New(rd, cfg.Cache),
+		cfg:     cfg,
+
+		protectionSecret: protectionSecret,
+		imageSlots:       make(chan struct{}, cfg.Images.Concurrency),
+		secrets:          box,
+		functionLogs:     make(chan functionCall, functionLogQueueLen),
 	}
 }
 
-func (s *ServerClient) trackRequest(subdomain, path, method string, statusCode int, responseTimeMs int, userAgent, ipAddress, referer string) {
+func (s *ServerClient) trackRequest(subdomain string, deploymentID uuid.UUID, path, method string, statusCode int, responseTimeMs int, userAgent, ipAddress, referer string) {
 	request := db.WebsiteAnalytics{
 		Subdomain:      subdomain,
 		Path:           path,
@@ -55,124 +111,145 @@ func (s *ServerClient) trackRequest(subdomain, path, method string, statusCode i
 		IPAddress:      ipAddress,
 		Referer:        referer,
 	}
+	if deploymentID != uuid.Nil {
+		request.DeploymentID = &deploymentID
+	}
 	s.qu.NewAnalyticsTask(request)
 }
 
 func (s *ServerClient) handleRequest(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 
-	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	ctx := r.Context()
 
-	if len(parts) == 0 || parts[0] == "" {
+	subdomain, path, ok := s.resolveSubdomain(ctx, r)
+	if !ok {
 		http.NotFound(w, r)
 		return
 	}
 
-	subdomain := parts[0]
-
-	path := "/index.html"
-	if len(parts) > 1 {
-		path = "/" + strings.Join(parts[1:], "/")
-	}
-
-	objectKey := subdomain + path
-
-	ctx := r.Context()
-
-	cachedJSON, err := s.rd.Get(ctx, objectKey)
-	if err == nil {
-		var cachedEntry CacheEntry
-		if err := json.Unmarshal([]byte(cachedJSON), &cachedEntry); err == nil {
-			for k, vv := range cachedEntry.Header {
-				for _, v := range vv {
-					w.Header().Add(k, v)
-				}
-			}
-			w.WriteHeader(cachedEntry.Status)
-			_, _ = w.Write([]byte(cachedEntry.Body))
-
-			fmt.Printf("Served from DB cache: %s/%s\n", subdomain, path)
-
-			responseTime := int(time.Since(start).Milliseconds())
-			s.trackRequest(
-				subdomain,
-				path,
-				r.Method,
-				cachedEntry.Status,
-				responseTime,
-				r.UserAgent(),
-				strings.Split(r.RemoteAddr, ":")[0],
-				r.Referer(),
-			)
-			return
-		}
-	}
-
-	reader, err := s.storage.GetObject(ctx, objectKey)
-	if err != nil {
+	site, ok := s.loadSite(ctx, subdomain)
+	if !ok {
 		http.NotFound(w, r)
 		return
 	}
-	defer reader.Close()
 
-	switch {
-	case strings.HasSuffix(path, ".html"):
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	case strings.HasSuffix(path, ".js"):
-		w.Header().Set("Content-Type", "application/javascript")
-	case strings.HasSuffix(path, ".css"):
-		w.Header().Set("Content-Type", "text/css")
-	case strings.HasSuffix(path, ".svg"):
-		w.Header().Set("Content-Type", "image/svg+xml")
+	if !s.checkFirewall(w, r, site) {
+		return
 	}
 
-	w.Header().Set("Content-Security-Policy",
-		"default-src 'self'; "+
-			"script-src 'self' 'unsafe-inline'; "+
-			"style-src 'self' 'unsafe-inline'; "+
-			"img-src 'self' data:;")
+	s.splitTraffic(w, r, site, path)
 
-	responseBuffer := &strings.Builder{}
+	router := s.router(site)
 
-	writer := io.MultiWriter(w, responseBuffer)
-	_, err = io.Copy(writer, reader)
-	if err != nil {
-		log.Printf("Failed to write response for %s%s: %v", subdomain, path, err)
-		return
-	}
+	headers := append(site.HeaderPolicy.Headers(), router.Headers(path)...)
 
-	cachedEntry := CacheEntry{
-		Status: http.StatusOK,
-		Header: w.Header().Clone(),
-		Body:   responseBuffer.String(),
+	w = &headerWriter{
+		ResponseWriter: w,
+		headers:        append(headers, protectionHeaders(site)...),
 	}
 
-	cachedRes, err := json.Marshal(cachedEntry)
-	if err == nil {
-		s.rd.Set(ctx, objectKey, cachedRes, 10*time.Minute)
+	status, authorized := s.authorize(w, r, site)
+	if authorized {
+		status, path = s.dispatch(w, r, site, router, path)
 	}
 
 	responseTime := int(time.Since(start).Milliseconds())
 	s.trackRequest(
 		subdomain,
+		site.DeploymentID,
 		path,
 		r.Method,
-		http.StatusOK,
+		status,
 		responseTime,
 		r.UserAgent(),
-		strings.Split(r.RemoteAddr, ":")[0],
+		s.clientIP(r),
 		r.Referer(),
 	)
 }
 
+func (s *ServerClient) dispatch(w http.ResponseWriter, r *http.Request, site *Site, router *routing.Router, path string) (int, string) {
+	if path == imagePath {
+		return s.serveImage(w, r, site), path
+	}
+
+	if status, redirected := s.applyRedirect(w, r, router, path); redirected {
+		return status, path
+	}
+
+	path, _ = router.Rewrite(path)
+	path = normalizePath(path)
+
+	if route, ok := functions.Match(site.Functions, path); ok {
+		return s.invokeFunction(w, r, site, route), path
+	}
+
+	return s.serve(w, r, site, path), path
+}
+
 func (s *ServerClient) Run(ctx context.Context) error {
+	rt, err := wasm.New(ctx, s.cfg.Functions)
+	if err != nil {
+		return err
+	}
+	defer rt.Close(ctx)
+	s.functions = rt
+
+	go s.watchInvalidations(ctx)
+	go s.recordFunctionLogs(ctx)
+
+	if s.cfg.MetricsPort != "" {
+		go s.serveMetrics()
+	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.handleRequest)
 
+	var handler http.Handler = mux
+
+	if s.cfg.TLS.Port != "" {
+		manager, err := s.newCertManager()
+		if err != nil {
+			return err
+		}
+
+		handler = manager.HTTPHandler(mux)
+
+		go func() {
+			if err := s.serveTLS(manager, mux); err != nil {
+				log.Fatalf("TLS server stopped: %v", err)
+			}
+		}()
+	}
+
 	port := os.Getenv("PORT")
 
 	log.Println("Server running on", port)
 
-	return http.ListenAndServe(":"+port, mux)
+	return http.ListenAndServe(":"+port, handler)
+}
+
+func (s *ServerClient) watchInvalidations(ctx context.Context) {
+	sub := s.rd.Subscribe(ctx, utils.CacheInvalidationChannel)
+	defer sub.Close()
+
+	for msg := range sub.Channel() {
+		if err := s.cache.InvalidatePrefix(ctx, msg.Payload); err != nil {
+			log.Printf("Failed to invalidate cache for %s: %v", msg.Payload, err)
+		}
+	}
+}
+
+func (s *ServerClient) serveMetrics() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cache", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.cache.Stats())
+	})
+
+	log.Println("Metrics running on", s.cfg.MetricsPort)
+
+	if err := http.ListenAndServe(":"+s.cfg.MetricsPort, mux); err != nil {
+		log.Printf("metrics server stopped: %v", err)
+	}
 }
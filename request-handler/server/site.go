@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/chrollo-lucifer-12/shared/caching"
+	"github.com/chrollo-lucifer-12/shared/db"
+	"github.com/chrollo-lucifer-12/shared/security"
+	"github.com/chrollo-lucifer-12/shared/utils"
+	"github.com/google/uuid"
+)
+
+const siteCacheTTL = 10 * time.Minute
+
+type SiteDeployment struct {
+	DeploymentID uuid.UUID           `json:"deployment_id"`
+	Prefix       string              `json:"prefix"`
+	Routes       json.RawMessage     `json:"routes,omitempty"`
+	Protection   security.Protection `json:"protection"`
+	Functions    []string            `json:"functions,omitempty"`
+}
+
+type Canary struct {
+	SiteDeployment
+	Weight int `json:"weight"`
+}
+
+type Site struct {
+	SiteDeployment
+	ProjectID    uuid.UUID             `json:"project_id"`
+	SubDomain    string                `json:"sub_domain"`
+	ServingMode  string                `json:"serving_mode"`
+	HeaderPolicy security.HeaderPolicy `json:"header_policy"`
+	Firewall     security.Firewall     `json:"firewall"`
+	CachePolicy  caching.Policy        `json:"cache_policy"`
+	Canaries     []Canary              `json:"canaries,omitempty"`
+}
+
+func newSite(label string, project db.Project) Site {
+	return Site{
+		ProjectID:    project.ID,
+		SubDomain:    label,
+		ServingMode:  project.ServingMode,
+		HeaderPolicy: project.HeaderPolicy.Data(),
+		Firewall:     project.Firewall.Data(),
+		CachePolicy:  project.CachePolicy.Data(),
+	}
+}
+
+func newSiteDeployment(project db.Project, deployment db.Deployment) SiteDeployment {
+	return SiteDeployment{
+		DeploymentID: deployment.ID,
+		Prefix:       utils.GetDeploymentPrefix(project.SubDomain, deployment.Sequence),
+		Routes:       json.RawMessage(deployment.Routes),
+		Protection:   security.ResolveProtection(project.Protection.Data(), deployment.Protection.Data()),
+		Functions:    deployment.Functions,
+	}
+}
+
+func (site *Site) setDeployment(project db.Project, deployment db.Deployment) {
+	site.SiteDeployment = newSiteDeployment(project, deployment)
+}
+
+func (s *ServerClient) loadCanaries(ctx context.Context, project db.Project) []Canary {
+	split := project.TrafficSplit.Data().Canaries()
+	if len(split) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(split))
+	for _, c := range split {
+		ids = append(ids, c.DeploymentID)
+	}
+
+	deployments, err := s.db.GetSuccessfulDeployments(ctx, project.ID, ids)
+	if err != nil {
+		log.Printf("Failed to load canary deployments for %s: %v", project.SubDomain, err)
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]db.Deployment, len(deployments))
+	for _, d := range deployments {
+		byID[d.ID] = d
+	}
+
+	var canaries []Canary
+	for _, c := range split {
+		deployment, ok := byID[c.DeploymentID]
+		if !ok || (project.ActiveDeploymentID != nil && *project.ActiveDeploymentID == c.DeploymentID) {
+			continue
+		}
+		canaries = append(canaries, Canary{
+			SiteDeployment: newSiteDeployment(project, deployment),
+			Weight:         c.Weight,
+		})
+	}
+
+	return canaries
+}
+
+func (s *ServerClient) lookupSite(ctx context.Context, label string) Site {
+	if subdomain, sequence, ok := utils.ParsePreviewSubdomain(label); ok {
+		if project, err := s.db.GetProjectBySlug(ctx, subdomain); err == nil {
+			site := newSite(label, project)
+
+			deployment, err := s.db.GetDeploymentBySequence(ctx, project.ID, sequence)
+			if err == nil && deployment.Status == "SUCCESS" {
+				site.setDeployment(project, deployment)
+			}
+
+			return site
+		}
+	}
+
+	project, err := s.db.GetProjectBySlug(ctx, label)
+	if err != nil {
+		return Site{SubDomain: label}
+	}
+
+	site := newSite(label, project)
+
+	if deployment, err := s.db.GetActiveDeployment(ctx, project); err == nil {
+		site.setDeployment(project, deployment)
+		site.Canaries = s.loadCanaries(ctx, project)
+	}
+
+	return site
+}
+
+func (site *Site) prepareFirewall() {
+	if err := site.Firewall.Normalize(); err != nil {
+		log.Printf("Invalid firewall for %s, ignoring it: %v", site.SubDomain, err)
+		site.Firewall = security.Firewall{}
+	}
+}
+
+func (s *ServerClient) loadSite(ctx context.Context, subdomain string) (*Site, bool) {
+	key := utils.GetSiteCacheKey(subdomain)
+
+	if cached, err := s.rd.Get(ctx, key); err == nil {
+		var site Site
+		if err := json.Unmarshal([]byte(cached), &site); err == nil {
+			site.prepareFirewall()
+			return &site, site.Prefix != ""
+		}
+	}
+
+	site := s.lookupSite(ctx, subdomain)
+	site.prepareFirewall()
+
+	ttl := siteCacheTTL
+	if site.Prefix == "" {
+		ttl = domainNegativeCacheTTL
+	}
+
+	if data, err := json.Marshal(site); err == nil {
+		s.rd.Set(ctx, key, data, ttl)
+	}
+
+	return &site, site.Prefix != ""
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"io"
+	"sync"
+)
+
+const (
+	copyBufferSize   = 32 << 10
+	teeInitialBuffer = 64 << 10
+)
+
+var copyBuffers = sync.Pool{
+	New: func() any {
+		buf := make([]byte, copyBufferSize)
+		return &buf
+	},
+}
+
+func copyBuffer(dst io.Writer, src io.Reader) (int64, error) {
+	buf := copyBuffers.Get().(*[]byte)
+	defer copyBuffers.Put(buf)
+	return io.CopyBuffer(dst, src, *buf)
+}
+
+func copyN(dst io.Writer, src io.Reader, n int64) (int64, error) {
+	written, err := copyBuffer(dst, io.LimitReader(src, n))
+	if written < n && err == nil {
+		err = io.EOF
+	}
+	return written, err
+}
+
+type cacheTee struct {
+	buf      []byte
+	limit    int64
+	overflow bool
+}
+
+func newCacheTee(size, limit int64) *cacheTee {
+	capacity := size
+	if capacity < 0 || capacity > limit {
+		capacity = min(limit, teeInitialBuffer)
+	}
+	return &cacheTee{buf: make([]byte, 0, capacity), limit: limit}
+}
+
+func (t *cacheTee) Write(p []byte) (int, error) {
+	if t.overflow {
+		return len(p), nil
+	}
+	if int64(len(t.buf)+len(p)) > t.limit {
+		t.overflow = true
+		t.buf = nil
+		return len(p), nil
+	}
+	t.buf = append(t.buf, p...)
+	return len(p), nil
+}
+
+func (t *cacheTee) Bytes() ([]byte, bool) {
+	return t.buf, !t.overflow
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/chrollo-lucifer-12/shared/certs"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+type TLSConfig struct {
+	Port     string
+	CertFile string
+	KeyFile  string
+	ACME     certs.Config
+}
+
+func (s *ServerClient) hostPolicy(ctx context.Context, host string) error {
+	host = normalizeHost(host)
+
+	if s.cfg.BaseDomain != "" && strings.HasSuffix(host, "."+s.cfg.BaseDomain) {
+		label := strings.TrimSuffix(host, "."+s.cfg.BaseDomain)
+		if label != "" && !strings.Contains(label, ".") {
+			if _, ok := s.loadSite(ctx, label); ok {
+				return nil
+			}
+		}
+		return fmt.Errorf("no site for %s", host)
+	}
+
+	if _, ok := s.lookupCustomDomain(ctx, host); ok {
+		return nil
+	}
+
+	return fmt.Errorf("%s is not a verified custom domain", host)
+}
+
+func (s *ServerClient) newCertManager() (*autocert.Manager, error) {
+	return certs.NewManager(s.cfg.TLS.ACME, certs.NewCache(s.db), s.hostPolicy)
+}
+
+func (s *ServerClient) serveTLS(manager *autocert.Manager, handler http.Handler) error {
+	var wildcard *tls.Certificate
+	if s.cfg.TLS.CertFile != "" && s.cfg.TLS.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(s.cfg.TLS.CertFile, s.cfg.TLS.KeyFile)
+		if err != nil {
+			return fmt.Errorf("load base domain certificate: %w", err)
+		}
+		wildcard = &cert
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1", acme.ALPNProto},
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if wildcard != nil && wildcard.Leaf != nil && wildcard.Leaf.VerifyHostname(hello.ServerName) == nil {
+				return wildcard, nil
+			}
+			return manager.GetCertificate(hello)
+		},
+	}
+
+	server := &http.Server{
+		Addr:      ":" + s.cfg.TLS.Port,
+		Handler:   handler,
+		TLSConfig: tlsConfig,
+	}
+
+	log.Println("TLS server running on", s.cfg.TLS.Port)
+
+	return server.ListenAndServeTLS("", "")
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chrollo-lucifer-12/shared/traffic"
+	"github.com/google/uuid"
+)
+
+const trafficCookieTTL = 24 * time.Hour
+
+func (site *Site) variant(id uuid.UUID) (SiteDeployment, bool) {
+	if id == site.DeploymentID {
+		return site.SiteDeployment, true
+	}
+	for _, canary := range site.Canaries {
+		if canary.DeploymentID == id {
+			return canary.SiteDeployment, true
+		}
+	}
+	return SiteDeployment{}, false
+}
+
+func (site *Site) pickVariant() SiteDeployment {
+	n := rand.IntN(100)
+	for _, canary := range site.Canaries {
+		if n < canary.Weight {
+			return canary.SiteDeployment
+		}
+		n -= canary.Weight
+	}
+	return site.SiteDeployment
+}
+
+func (s *ServerClient) splitTraffic(w http.ResponseWriter, r *http.Request, site *Site, path string) {
+	if len(site.Canaries) == 0 {
+		return
+	}
+
+	if cookie, err := r.Cookie(traffic.CookieName); err == nil {
+		if id, err := uuid.Parse(cookie.Value); err == nil {
+			if deployment, ok := site.variant(id); ok {
+				site.SiteDeployment = deployment
+				return
+			}
+		}
+	}
+
+	site.SiteDeployment = site.pickVariant()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     traffic.CookieName,
+		Value:    site.DeploymentID.String(),
+		Path:     strings.TrimSuffix(r.URL.Path, path) + "/",
+		MaxAge:   int(trafficCookieTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   s.secureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chrollo-lucifer-12/shared/traffic"
+	"github.com/google/uuid"
+	"gotest.tools/v3/assert"
+)
+
+func newTrafficSite(weights ...int) *Site {
+	site := &Site{SiteDeployment: SiteDeployment{DeploymentID: uuid.New(), Prefix: "blog1"}}
+	for _, weight := range weights {
+		site.Canaries = append(site.Canaries, Canary{
+			SiteDeployment: SiteDeployment{DeploymentID: uuid.New(), Prefix: "canary"},
+			Weight:         weight,
+		})
+	}
+	return site
+}
+
+func TestPickVariantFollowsWeights(t *testing.T) {
+	site := newTrafficSite(20, 30)
+
+	counts := map[uuid.UUID]int{}
+	for range 10000 {
+		counts[site.pickVariant().DeploymentID]++
+	}
+
+	assertNear := func(id uuid.UUID, want int) {
+		got := counts[id]
+		assert.Assert(t, got > want-400 && got < want+400, "got %d, want about %d", got, want)
+	}
+	assertNear(site.Canaries[0].DeploymentID, 2000)
+	assertNear(site.Canaries[1].DeploymentID, 3000)
+	assertNear(site.DeploymentID, 5000)
+}
+
+func TestPickVariantFullWeight(t *testing.T) {
+	site := newTrafficSite(100)
+
+	for range 100 {
+		assert.Equal(t, site.pickVariant().DeploymentID, site.Canaries[0].DeploymentID)
+	}
+}
+
+func TestSplitTrafficIsSticky(t *testing.T) {
+	s := &ServerClient{}
+	site := newTrafficSite(50)
+	canary := site.Canaries[0].DeploymentID
+
+	r := httptest.NewRequest("GET", "/about", nil)
+	r.AddCookie(&http.Cookie{Name: traffic.CookieName, Value: canary.String()})
+	w := httptest.NewRecorder()
+
+	s.splitTraffic(w, r, site, "/about")
+
+	assert.Equal(t, site.DeploymentID, canary)
+	assert.Equal(t, len(w.Result().Cookies()), 0)
+}
+
+func TestSplitTrafficSetsCookie(t *testing.T) {
+	for _, tc := range []struct {
+		trustProxy bool
+		secure     bool
+	}{
+		{trustProxy: false, secure: false},
+		{trustProxy: true, secure: true},
+	} {
+		s := &ServerClient{cfg: Config{TrustProxy: tc.trustProxy}}
+		site := newTrafficSite(50)
+		stale := uuid.New()
+
+		r := httptest.NewRequest("GET", "/blog/about", nil)
+		r.Header.Set("X-Forwarded-Proto", "https")
+		r.AddCookie(&http.Cookie{Name: traffic.CookieName, Value: stale.String()})
+		w := httptest.NewRecorder()
+
+		s.splitTraffic(w, r, site, "/about")
+
+		cookies := w.Result().Cookies()
+		assert.Equal(t, len(cookies), 1)
+		assert.Equal(t, cookies[0].Value, site.DeploymentID.String())
+		assert.Equal(t, cookies[0].Path, "/blog/")
+		assert.Equal(t, cookies[0].Secure, tc.secure)
+		assert.Assert(t, site.DeploymentID != stale)
+	}
+}
+
+func TestSplitTrafficWithoutCanaries(t *testing.T) {
+	s := &ServerClient{}
+	site := newTrafficSite()
+	active := site.DeploymentID
+
+	w := httptest.NewRecorder()
+	s.splitTraffic(w, httptest.NewRequest("GET", "/", nil), site, "/")
+
+	assert.Equal(t, site.DeploymentID, active)
+	assert.Equal(t, len(w.Result().Cookies()), 0)
+}
@@ -0,0 +1,299 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chrollo-lucifer-12/shared/functions"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+const (
+	pageSize     = 64 << 10
+	maxLogBytes  = 64 << 10
+	maxLogLines  = 200
+	responseSlop = 64 << 10
+)
+
+var (
+	ErrBusy             = errors.New("function concurrency limit reached")
+	ErrRequestTooLarge  = errors.New("function request is too large")
+	ErrTimeout          = errors.New("function timed out")
+	ErrInvalidResponse  = errors.New("function returned an invalid response")
+	ErrResponseTooLarge = errors.New("function response is too large")
+)
+
+type Config struct {
+	Timeout        time.Duration
+	MemoryLimitMB  int
+	MaxConcurrency int
+	MaxBodyBytes   int64
+	MaxModules     int
+}
+
+type Invocation struct {
+	Key     string
+	Load    func(ctx context.Context) ([]byte, error)
+	Env     map[string]string
+	Request functions.Request
+}
+
+type Result struct {
+	Response functions.Response
+	Logs     []string
+	Duration time.Duration
+}
+
+type module struct {
+	compiled wazero.CompiledModule
+	refs     int
+	lastUsed time.Time
+}
+
+type Runtime struct {
+	rt    wazero.Runtime
+	cfg   Config
+	slots chan struct{}
+
+	mu      sync.Mutex
+	modules map[string]*module
+}
+
+func New(ctx context.Context, cfg Config) (*Runtime, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MemoryLimitMB <= 0 {
+		cfg.MemoryLimitMB = 128
+	}
+	if cfg.MaxConcurrency <= 0 {
+		cfg.MaxConcurrency = 32
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = 4 << 20
+	}
+	if cfg.MaxModules <= 0 {
+		cfg.MaxModules = 128
+	}
+
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(uint32(cfg.MemoryLimitMB<<20/pageSize)).
+		WithCloseOnContextDone(true))
+
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+		rt.Close(ctx)
+		return nil, err
+	}
+
+	return &Runtime{
+		rt:      rt,
+		cfg:     cfg,
+		slots:   make(chan struct{}, cfg.MaxConcurrency),
+		modules: make(map[string]*module),
+	}, nil
+}
+
+func (r *Runtime) MaxBodyBytes() int64 {
+	return r.cfg.MaxBodyBytes
+}
+
+func (r *Runtime) Close(ctx context.Context) error {
+	return r.rt.Close(ctx)
+}
+
+func (r *Runtime) acquire(ctx context.Context, inv Invocation) (*module, error) {
+	r.mu.Lock()
+	if m, ok := r.modules[inv.Key]; ok {
+		m.refs++
+		m.lastUsed = time.Now()
+		r.mu.Unlock()
+		return m, nil
+	}
+	r.mu.Unlock()
+
+	data, err := inv.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled, err := r.rt.CompileModule(ctx, data)
+	if err != nil {
+		return nil, fmt.Errorf("compiling function: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m, ok := r.modules[inv.Key]; ok {
+		compiled.Close(ctx)
+		m.refs++
+		m.lastUsed = time.Now()
+		return m, nil
+	}
+
+	m := &module{compiled: compiled, refs: 1, lastUsed: time.Now()}
+	r.modules[inv.Key] = m
+	r.evict(ctx)
+
+	return m, nil
+}
+
+func (r *Runtime) release(m *module) {
+	r.mu.Lock()
+	m.refs--
+	r.mu.Unlock()
+}
+
+func (r *Runtime) evict(ctx context.Context) {
+	if len(r.modules) <= r.cfg.MaxModules {
+		return
+	}
+
+	idle := make([]string, 0, len(r.modules))
+	for key, m := range r.modules {
+		if m.refs == 0 {
+			idle = append(idle, key)
+		}
+	}
+	sort.Slice(idle, func(i, j int) bool {
+		return r.modules[idle[i]].lastUsed.Before(r.modules[idle[j]].lastUsed)
+	})
+
+	for _, key := range idle {
+		if len(r.modules) <= r.cfg.MaxModules {
+			return
+		}
+		r.modules[key].compiled.Close(ctx)
+		delete(r.modules, key)
+	}
+}
+
+type limitedBuffer struct {
+	bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.Len(); len(p) > remaining {
+		b.overflow = true
+		p = p[:max(remaining, 0)]
+	}
+	b.Buffer.Write(p)
+	return len(p), nil
+}
+
+func splitLogs(b *limitedBuffer) []string {
+	text := strings.TrimRight(b.String(), "\n")
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.Split(text, "\n")
+	if len(lines) > maxLogLines {
+		lines = lines[:maxLogLines]
+		b.overflow = true
+	}
+	if b.overflow {
+		lines = append(lines, "[log output truncated]")
+	}
+
+	return lines
+}
+
+func (r *Runtime) Invoke(ctx context.Context, inv Invocation) (*Result, error) {
+	select {
+	case r.slots <- struct{}{}:
+		defer func() { <-r.slots }()
+	default:
+		return nil, ErrBusy
+	}
+
+	if int64(len(inv.Request.Body)) > r.cfg.MaxBodyBytes {
+		return nil, ErrRequestTooLarge
+	}
+
+	m, err := r.acquire(ctx, inv)
+	if err != nil {
+		return nil, err
+	}
+	defer r.release(m)
+
+	input, err := json.Marshal(inv.Request)
+	if err != nil {
+		return nil, err
+	}
+
+	stdout := &limitedBuffer{limit: int(r.cfg.MaxBodyBytes*4/3) + responseSlop}
+	stderr := &limitedBuffer{limit: maxLogBytes}
+
+	deadline := time.Now().Add(r.cfg.Timeout)
+
+	config := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs(inv.Request.Path).
+		WithStdin(bytes.NewReader(input)).
+		WithStdout(stdout).
+		WithStderr(stderr).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithNanosleep(func(ns int64) {
+			time.Sleep(min(time.Duration(ns), time.Until(deadline)))
+		}).
+		WithRandSource(rand.Reader)
+
+	keys := make([]string, 0, len(inv.Env))
+	for key := range inv.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		config = config.WithEnv(key, inv.Env[key])
+	}
+
+	runCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	start := time.Now()
+	instance, err := r.rt.InstantiateModule(runCtx, m.compiled, config)
+	if instance != nil {
+		instance.Close(ctx)
+	}
+
+	result := &Result{Duration: time.Since(start), Logs: splitLogs(stderr)}
+
+	var exitErr *sys.ExitError
+	switch {
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 0:
+	case errors.Is(err, context.DeadlineExceeded):
+		return result, ErrTimeout
+	case err != nil:
+		return result, err
+	}
+
+	if stdout.overflow {
+		return result, ErrResponseTooLarge
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), &result.Response); err != nil {
+		return result, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	if result.Response.Status == 0 {
+		result.Response.Status = 200
+	}
+	if result.Response.Status < 100 || result.Response.Status > 599 {
+		return result, fmt.Errorf("%w: status %d", ErrInvalidResponse, result.Response.Status)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,113 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chrollo-lucifer-12/shared/functions"
+	"gotest.tools/v3/assert"
+)
+
+func buildEchoModule(t *testing.T) []byte {
+	t.Helper()
+
+	out := filepath.Join(t.TempDir(), "echo.wasm")
+	cmd := exec.Command("go", "build", "-o", out, "./testdata/echo")
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm", "GOFLAGS=")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("cannot build wasip1 module: %v\n%s", err, output)
+	}
+
+	data, err := os.ReadFile(out)
+	assert.NilError(t, err)
+	return data
+}
+
+func newTestRuntime(t *testing.T, cfg Config) *Runtime {
+	ctx := context.Background()
+	rt, err := New(ctx, cfg)
+	assert.NilError(t, err)
+	t.Cleanup(func() { rt.Close(ctx) })
+	return rt
+}
+
+func invocation(module []byte, loads *atomic.Int32, path string) Invocation {
+	return Invocation{
+		Key: "echo",
+		Load: func(context.Context) ([]byte, error) {
+			loads.Add(1)
+			return module, nil
+		},
+		Env: map[string]string{"GREETING": "hello"},
+		Request: functions.Request{
+			Method: "POST",
+			Path:   path,
+			Body:   []byte("ping"),
+		},
+	}
+}
+
+func TestInvoke(t *testing.T) {
+	module := buildEchoModule(t)
+	rt := newTestRuntime(t, Config{})
+	ctx := context.Background()
+
+	var loads atomic.Int32
+
+	result, err := rt.Invoke(ctx, invocation(module, &loads, "/api/echo"))
+	assert.NilError(t, err)
+	assert.Equal(t, result.Response.Status, 201)
+	assert.Equal(t, string(result.Response.Body), "ping")
+	assert.DeepEqual(t, result.Response.Headers["X-Greeting"], []string{"hello"})
+	assert.DeepEqual(t, result.Logs, []string{"handling POST /api/echo"})
+
+	_, err = rt.Invoke(ctx, invocation(module, &loads, "/api/echo"))
+	assert.NilError(t, err)
+	assert.Equal(t, loads.Load(), int32(1))
+}
+
+func TestInvokeErrors(t *testing.T) {
+	module := buildEchoModule(t)
+	rt := newTestRuntime(t, Config{Timeout: time.Second, MaxBodyBytes: 16})
+	ctx := context.Background()
+
+	var loads atomic.Int32
+
+	result, err := rt.Invoke(ctx, invocation(module, &loads, "/api/sleep"))
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.DeepEqual(t, result.Logs, []string{"handling POST /api/sleep"})
+
+	_, err = rt.Invoke(ctx, invocation(module, &loads, "/api/garbage"))
+	assert.ErrorIs(t, err, ErrInvalidResponse)
+
+	_, err = rt.Invoke(ctx, invocation(module, &loads, "/api/fail"))
+	assert.Assert(t, err != nil)
+	assert.Assert(t, !errors.Is(err, ErrTimeout))
+
+	inv := invocation(module, &loads, "/api/echo")
+	inv.Request.Body = make([]byte, 17)
+	_, err = rt.Invoke(ctx, inv)
+	assert.ErrorIs(t, err, ErrRequestTooLarge)
+}
+
+func TestInvokeBusy(t *testing.T) {
+	rt := newTestRuntime(t, Config{MaxConcurrency: 1})
+	rt.slots <- struct{}{}
+
+	_, err := rt.Invoke(context.Background(), Invocation{Key: "busy"})
+	assert.ErrorIs(t, err, ErrBusy)
+}
+
+func TestSplitLogs(t *testing.T) {
+	b := &limitedBuffer{limit: 8}
+	b.Write([]byte("one\ntwo\nthree\n"))
+
+	assert.DeepEqual(t, splitLogs(b), []string{"one", "two", "[log output truncated]"})
+	assert.DeepEqual(t, splitLogs(&limitedBuffer{limit: 8}), []string(nil))
+}
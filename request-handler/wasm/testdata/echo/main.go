@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+type request struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Body   []byte `json:"body"`
+}
+
+type response struct {
+	Status  int                 `json:"status"`
+	Headers map[string][]string `json:"headers"`
+	Body    []byte              `json:"body"`
+}
+
+func main() {
+	var req request
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintln(os.Stderr, "handling", req.Method, req.Path)
+
+	switch req.Path {
+	case "/api/sleep":
+		time.Sleep(time.Minute)
+	case "/api/fail":
+		os.Exit(2)
+	case "/api/garbage":
+		fmt.Print("not json")
+		return
+	}
+
+	json.NewEncoder(os.Stdout).Encode(response{
+		Status:  201,
+		Headers: map[string][]string{"X-Greeting": {os.Getenv("GREETING")}},
+		Body:    req.Body,
+	})
+}
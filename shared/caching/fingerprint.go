@@ -0,0 +1,68 @@
+package caching
+
+import (
+	"path"
+	"strings"
+)
+
+const hashLength = 8
+
+func isHex(token string) bool {
+	letter, digit := false, false
+	for _, c := range token {
+		switch {
+		case c >= '0' && c <= '9':
+			digit = true
+		case c >= 'a' && c <= 'f':
+			letter = true
+		default:
+			return false
+		}
+	}
+	return letter && digit && len(token) >= hashLength
+}
+
+func isBase64Hash(token string) bool {
+	if len(token) != hashLength {
+		return false
+	}
+
+	mixed := false
+	for i, c := range token {
+		switch {
+		case c >= '0' && c <= '9', c == '-', c == '_':
+			mixed = true
+		case c >= 'A' && c <= 'Z':
+			if i > 0 {
+				mixed = true
+			}
+		case c >= 'a' && c <= 'z':
+		default:
+			return false
+		}
+	}
+	return mixed
+}
+
+func IsFingerprinted(name string) bool {
+	parts := strings.Split(path.Base(name), ".")
+	if len(parts) < 2 {
+		return false
+	}
+
+	for _, part := range parts[:len(parts)-1] {
+		if isHex(part) {
+			return true
+		}
+
+		if i := strings.LastIndexByte(part, '-'); i >= 0 && isHex(part[i+1:]) {
+			return true
+		}
+
+		if n := len(part) - hashLength; n > 0 && part[n-1] == '-' && isBase64Hash(part[n:]) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,71 @@
+package caching
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	Immutable  = "public, max-age=31536000, immutable"
+	Revalidate = "public, max-age=0, must-revalidate"
+	NoCache    = "no-cache"
+)
+
+const maxValueLength = 512
+
+var directivePattern = regexp.MustCompile(`^[A-Za-z-]+(=([0-9]+|"[^"]*"|[A-Za-z0-9-]+))?$`)
+
+type Policy struct {
+	HTML   *string `json:"html,omitempty"`
+	Assets *string `json:"assets,omitempty"`
+	Other  *string `json:"other,omitempty"`
+}
+
+func validValue(value string) error {
+	if value == "" {
+		return nil
+	}
+	if len(value) > maxValueLength {
+		return fmt.Errorf("value is longer than %d characters", maxValueLength)
+	}
+
+	for _, directive := range strings.Split(value, ",") {
+		directive = strings.TrimSpace(directive)
+		if !directivePattern.MatchString(directive) {
+			return fmt.Errorf("invalid directive %q", directive)
+		}
+	}
+
+	return nil
+}
+
+func (p Policy) Validate() error {
+	for name, value := range map[string]*string{"html": p.HTML, "assets": p.Assets, "other": p.Other} {
+		if value == nil {
+			continue
+		}
+		if err := validValue(*value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func pick(override *string, fallback string) string {
+	if override != nil {
+		return *override
+	}
+	return fallback
+}
+
+func (p Policy) Value(html, fingerprinted bool) string {
+	switch {
+	case html:
+		return pick(p.HTML, Revalidate)
+	case fingerprinted:
+		return pick(p.Assets, Immutable)
+	default:
+		return pick(p.Other, Revalidate)
+	}
+}
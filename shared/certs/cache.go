@@ -0,0 +1,64 @@
+package certs
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"time"
+
+	"github.com/chrollo-lucifer-12/shared/db"
+	"golang.org/x/crypto/acme/autocert"
+	"gorm.io/gorm"
+)
+
+type Cache struct {
+	db *db.DB
+}
+
+func NewCache(d *db.DB) *Cache {
+	return &Cache{db: d}
+}
+
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	cert, err := c.db.GetCertificate(ctx, key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, autocert.ErrCacheMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cert.Data, nil
+}
+
+func (c *Cache) Put(ctx context.Context, key string, data []byte) error {
+	return c.db.SaveCertificate(ctx, &db.Certificate{
+		Key:       key,
+		Data:      data,
+		ExpiresAt: certificateExpiry(data),
+	})
+}
+
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	return c.db.DeleteCertificate(ctx, key)
+}
+
+func certificateExpiry(data []byte) *time.Time {
+	for len(data) > 0 {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil
+		}
+		return &cert.NotAfter
+	}
+	return nil
+}
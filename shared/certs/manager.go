@@ -0,0 +1,114 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const DefaultRenewBefore = 30 * 24 * time.Hour
+
+type Config struct {
+	DirectoryURL string
+	CABundle     string
+	Email        string
+	RenewBefore  time.Duration
+}
+
+func (c Config) RenewWindow() time.Duration {
+	if c.RenewBefore <= 0 {
+		return DefaultRenewBefore
+	}
+	return c.RenewBefore
+}
+
+func acmeClient(cfg Config) (*acme.Client, error) {
+	client := &acme.Client{DirectoryURL: cfg.DirectoryURL}
+	if client.DirectoryURL == "" {
+		client.DirectoryURL = autocert.DefaultACMEDirectory
+	}
+
+	if cfg.CABundle == "" {
+		return client, nil
+	}
+
+	pemData, err := os.ReadFile(cfg.CABundle)
+	if err != nil {
+		return nil, fmt.Errorf("read ACME CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pemData) {
+		return nil, fmt.Errorf("ACME CA bundle %s has no certificates", cfg.CABundle)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	client.HTTPClient = &http.Client{Transport: transport}
+
+	return client, nil
+}
+
+func NewManager(cfg Config, cache autocert.Cache, policy autocert.HostPolicy) (*autocert.Manager, error) {
+	client, err := acmeClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &autocert.Manager{
+		Prompt:      autocert.AcceptTOS,
+		Cache:       cache,
+		HostPolicy:  policy,
+		RenewBefore: cfg.RenewWindow(),
+		Client:      client,
+		Email:       cfg.Email,
+	}, nil
+}
+
+type renewalCache struct {
+	autocert.Cache
+	key string
+}
+
+func (c renewalCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if key == c.key {
+		return nil, autocert.ErrCacheMiss
+	}
+	return c.Cache.Get(ctx, key)
+}
+
+func HostName(key string) string {
+	return strings.TrimSuffix(key, "+rsa")
+}
+
+func Renew(ctx context.Context, cfg Config, cache autocert.Cache, key string, policy autocert.HostPolicy) error {
+	manager, err := NewManager(cfg, renewalCache{Cache: cache, key: key}, policy)
+	if err != nil {
+		return err
+	}
+	manager.HTTPHandler(nil)
+
+	hello := &tls.ClientHelloInfo{ServerName: HostName(key)}
+	if !strings.HasSuffix(key, "+rsa") {
+		hello.SignatureSchemes = []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256}
+		hello.SupportedCurves = []tls.CurveID{tls.CurveP256}
+		hello.CipherSuites = []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}
+	}
+
+	if _, err := manager.GetCertificate(hello); err != nil {
+		return fmt.Errorf("renew %s: %w", key, err)
+	}
+
+	return nil
+}
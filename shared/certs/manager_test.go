@@ -0,0 +1,161 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+	"gotest.tools/v3/assert"
+)
+
+func TestRenewWindow(t *testing.T) {
+	assert.Equal(t, Config{}.RenewWindow(), DefaultRenewBefore)
+	assert.Equal(t, Config{RenewBefore: time.Hour}.RenewWindow(), time.Hour)
+}
+
+func TestRenewalCacheHidesOnlyRenewedKey(t *testing.T) {
+	ctx := context.Background()
+	dir := autocert.DirCache(t.TempDir())
+
+	assert.NilError(t, dir.Put(ctx, "example.com", []byte("old")))
+	assert.NilError(t, dir.Put(ctx, "acme_account+key", []byte("account")))
+
+	cache := renewalCache{Cache: dir, key: "example.com"}
+
+	_, err := cache.Get(ctx, "example.com")
+	assert.Equal(t, err, autocert.ErrCacheMiss)
+
+	data, err := cache.Get(ctx, "acme_account+key")
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), "account")
+
+	assert.NilError(t, cache.Put(ctx, "example.com", []byte("new")))
+	data, err = dir.Get(ctx, "example.com")
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), "new")
+}
+
+func TestCertificateExpiry(t *testing.T) {
+	notAfter := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		NotBefore:    notAfter.Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NilError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NilError(t, err)
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+
+	expiry := certificateExpiry(data)
+	assert.Assert(t, expiry != nil)
+	assert.Assert(t, expiry.Equal(notAfter))
+
+	assert.Assert(t, certificateExpiry([]byte("not a pem")) == nil)
+}
+
+func TestRenewRespectsHostPolicy(t *testing.T) {
+	ctx := context.Background()
+	dir := autocert.DirCache(t.TempDir())
+	assert.NilError(t, dir.Put(ctx, "removed.example.com", []byte("old")))
+
+	var checked atomic.Value
+	policy := func(_ context.Context, host string) error {
+		checked.Store(host)
+		return errors.New("not verified")
+	}
+
+	err := Renew(ctx, Config{DirectoryURL: "http://127.0.0.1:0/directory"}, dir, "removed.example.com+rsa", policy)
+	assert.ErrorContains(t, err, "not verified")
+	assert.Equal(t, checked.Load(), "removed.example.com")
+
+	data, err := dir.Get(ctx, "removed.example.com")
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), "old")
+}
+
+func TestHostName(t *testing.T) {
+	assert.Equal(t, HostName("example.com"), "example.com")
+	assert.Equal(t, HostName("example.com+rsa"), "example.com")
+}
+
+type deleteCountingCache struct {
+	autocert.Cache
+	deletes atomic.Int32
+}
+
+func (c *deleteCountingCache) Delete(ctx context.Context, key string) error {
+	c.deletes.Add(1)
+	return c.Cache.Delete(ctx, key)
+}
+
+func leafSerial(t *testing.T, data []byte) *big.Int {
+	for len(data) > 0 {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		assert.Assert(t, block != nil)
+		if block.Type == "CERTIFICATE" {
+			cert, err := x509.ParseCertificate(block.Bytes)
+			assert.NilError(t, err)
+			return cert.SerialNumber
+		}
+	}
+	t.Fatal("no certificate in cache entry")
+	return nil
+}
+
+func TestRenewAgainstPebble(t *testing.T) {
+	directory := os.Getenv("PEBBLE_DIRECTORY_URL")
+	if directory == "" {
+		t.Skip("PEBBLE_DIRECTORY_URL is not set, run `task test:pebble`")
+	}
+
+	ctx := context.Background()
+	cfg := Config{DirectoryURL: directory, CABundle: os.Getenv("PEBBLE_CA_BUNDLE")}
+	cache := &deleteCountingCache{Cache: autocert.DirCache(t.TempDir())}
+
+	manager, err := NewManager(cfg, cache, nil)
+	assert.NilError(t, err)
+	manager.HTTPHandler(nil)
+
+	hello := &tls.ClientHelloInfo{
+		ServerName:       "renew.example.test",
+		SignatureSchemes: []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+		SupportedCurves:  []tls.CurveID{tls.CurveP256},
+		CipherSuites:     []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+	}
+	_, err = manager.GetCertificate(hello)
+	assert.NilError(t, err)
+
+	issued, err := cache.Get(ctx, hello.ServerName)
+	assert.NilError(t, err)
+
+	assert.NilError(t, Renew(ctx, cfg, cache, hello.ServerName, nil))
+
+	renewed, err := cache.Get(ctx, hello.ServerName)
+	assert.NilError(t, err)
+
+	assert.Assert(t, leafSerial(t, issued).Cmp(leafSerial(t, renewed)) != 0)
+	assert.Equal(t, cache.deletes.Load(), int32(0))
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"github.com/chrollo-lucifer-12/shared/security"
+	"github.com/chrollo-lucifer-12/shared/utils"
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
 	"gorm.io/driver/postgres"
@@ -24,7 +27,8 @@ func NewDB(dsn string, ctx context.Context) (*DB, error) {
 		return nil, fmt.Errorf("No dsn")
 	}
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger:         logger.Default.LogMode(logger.Info),
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, err
@@ -38,7 +42,8 @@ func NewTestDB(dsn string, ctx context.Context) (*DB, error) {
 		return nil, fmt.Errorf("No dsn")
 	}
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger:         logger.Default.LogMode(logger.Info),
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, err
@@ -52,13 +57,100 @@ func (d *DB) Raw() *gorm.DB {
 }
 
 func (d *DB) MigrateDB() error {
-	err := d.db.AutoMigrate(&User{}, &Otp{}, &Session{}, &Project{}, &Deployment{}, &LogEvent{}, &Cache{}, &WebsiteAnalytics{})
+	err := d.db.AutoMigrate(&User{}, &Otp{}, &Session{}, &Project{}, &Deployment{}, &LogEvent{}, &Cache{}, &WebsiteAnalytics{}, &Certificate{}, &Domain{}, &EnvVar{})
 	if err != nil {
 		return err
 	}
 
 	_ = d.db.Exec("ALTER TABLE caches SET UNLOGGED").Error
 
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := migrateLegacyCustomDomains(tx); err != nil {
+			return err
+		}
+		return dropLegacyProjectColumns(tx)
+	})
+}
+
+type legacyCustomDomain struct {
+	ID           uuid.UUID
+	CustomDomain string
+	Verified     bool
+}
+
+func migrateLegacyCustomDomains(tx *gorm.DB) error {
+	m := tx.Migrator()
+	if !m.HasColumn(&Project{}, "custom_domain") {
+		return nil
+	}
+
+	verified := "false"
+	if m.HasColumn(&Project{}, "custom_domain_verified") {
+		verified = "COALESCE(custom_domain_verified, false)"
+	}
+
+	var legacy []legacyCustomDomain
+	err := tx.Raw("SELECT id, custom_domain, " + verified + " AS verified FROM projects WHERE COALESCE(custom_domain, '') <> '' ORDER BY created_at").
+		Scan(&legacy).Error
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, l := range legacy {
+		name := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(l.CustomDomain)), ".")
+		if name == "" {
+			continue
+		}
+
+		var existing []Domain
+		if err := tx.Where("name = ?", name).Find(&existing).Error; err != nil {
+			return err
+		}
+
+		alreadyVerified := false
+		migrated := false
+		for _, domain := range existing {
+			alreadyVerified = alreadyVerified || domain.Verified
+			migrated = migrated || domain.ProjectID == l.ID
+		}
+		if migrated {
+			continue
+		}
+
+		token, err := utils.GenerateToken()
+		if err != nil {
+			return err
+		}
+
+		domain := Domain{
+			ProjectID:         l.ID,
+			Name:              name,
+			VerificationToken: token,
+			Verified:          l.Verified && !alreadyVerified,
+		}
+		if domain.Verified {
+			domain.VerifiedAt = &now
+		}
+
+		if err := tx.Create(&domain).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func dropLegacyProjectColumns(tx *gorm.DB) error {
+	m := tx.Migrator()
+	for _, column := range []string{"custom_domain", "custom_domain_verified"} {
+		if !m.HasColumn(&Project{}, column) {
+			continue
+		}
+		if err := m.DropColumn(&Project{}, column); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -141,6 +233,14 @@ func (d *DB) GetProjectBySlug(ctx context.Context, slug string) (Project, error)
 	return first[Project](ctx, d.db, "sub_domain = ?", slug)
 }
 
+func (d *DB) GetProjectByCustomDomain(ctx context.Context, domain string) (Project, error) {
+	verified, err := first[Domain](ctx, d.db, "name = ? AND verified = ?", domain, true)
+	if err != nil {
+		return Project{}, err
+	}
+	return first[Project](ctx, d.db, "id = ?", verified.ProjectID)
+}
+
 func (d *DB) GetAllProjects(
 	ctx context.Context,
 	userID uuid.UUID,
@@ -176,6 +276,10 @@ func (d *DB) UpdateProject(ctx context.Context, id uuid.UUID, p Project) error {
 	return update[Project](ctx, d.db, "id = ?", p, id)
 }
 
+func (d *DB) UpdateProjectColumns(ctx context.Context, id uuid.UUID, p Project, columns ...string) error {
+	return d.db.WithContext(ctx).Model(&Project{}).Where("id = ?", id).Select(columns).Updates(&p).Error
+}
+
 func (d *DB) DeleteProject(ctx context.Context, id uuid.UUID) error {
 	return deleteBy[Project](ctx, d.db, "id = ?", id)
 }
@@ -202,6 +306,68 @@ func (d *DB) GetCache(ctx context.Context, key string) (datatypes.JSON, error) {
 	return cache.Value, err
 }
 
+func (d *DB) CreateDomain(ctx context.Context, domain *Domain) error {
+	return create(ctx, d.db, domain)
+}
+
+func (d *DB) GetDomainByID(ctx context.Context, id uuid.UUID) (Domain, error) {
+	return first[Domain](ctx, d.db, "id = ?", id)
+}
+
+func (d *DB) GetDomainsByName(ctx context.Context, name string) ([]Domain, error) {
+	return find[Domain](ctx, d.db, "name = ?", name)
+}
+
+func (d *DB) GetProjectDomains(ctx context.Context, projectID uuid.UUID) ([]Domain, error) {
+	return gorm.G[Domain](d.db).Where("project_id = ?", projectID).Order("created_at ASC").Find(ctx)
+}
+
+func (d *DB) GetDomainsToCheck(ctx context.Context, recheckBefore, unverifiedAfter time.Time) ([]Domain, error) {
+	return find[Domain](ctx, d.db,
+		"(verified = ? AND (last_checked_at IS NULL OR last_checked_at < ?)) OR (verified = ? AND COALESCE(unverified_since, created_at) > ?)",
+		true, recheckBefore, false, unverifiedAfter)
+}
+
+func (d *DB) RecordDomainCheck(ctx context.Context, id uuid.UUID, verified bool, checkErr string) error {
+	now := time.Now()
+	values := map[string]any{
+		"verified":        verified,
+		"last_checked_at": now,
+		"last_error":      checkErr,
+	}
+	if verified {
+		values["verified_at"] = gorm.Expr("COALESCE(verified_at, ?)", now)
+		values["unverified_since"] = nil
+	} else {
+		values["verified_at"] = nil
+		values["unverified_since"] = gorm.Expr("COALESCE(unverified_since, ?)", now)
+	}
+	return d.db.WithContext(ctx).Model(&Domain{}).Where("id = ?", id).Updates(values).Error
+}
+
+func (d *DB) DeleteDomain(ctx context.Context, id uuid.UUID) error {
+	return deleteBy[Domain](ctx, d.db, "id = ?", id)
+}
+
+func (d *DB) SaveCertificate(ctx context.Context, cert *Certificate) error {
+	return d.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"data", "expires_at", "updated_at"}),
+	}).Create(cert).Error
+}
+
+func (d *DB) GetCertificate(ctx context.Context, key string) (Certificate, error) {
+	return first[Certificate](ctx, d.db, "key = ?", key)
+}
+
+func (d *DB) DeleteCertificate(ctx context.Context, key string) error {
+	return deleteBy[Certificate](ctx, d.db, "key = ?", key)
+}
+
+func (d *DB) GetIssuedCertificates(ctx context.Context) ([]Certificate, error) {
+	return find[Certificate](ctx, d.db, "expires_at IS NOT NULL")
+}
+
 func (d *DB) CreateDeployment(ctx context.Context, dep *Deployment) error {
 	return create(ctx, d.db, dep)
 }
@@ -228,6 +394,10 @@ func (d *DB) GetLatestDeployment(ctx context.Context, projectID uuid.UUID) (Depl
 	return deployment, err
 }
 
+func (d *DB) GetDeploymentBySequence(ctx context.Context, projectID uuid.UUID, sequence int) (Deployment, error) {
+	return first[Deployment](ctx, d.db, "project_id = ? AND sequence = ?", projectID, sequence)
+}
+
 func (d *DB) GetAllDeployments(ctx context.Context, projectID uuid.UUID) ([]Deployment, error) {
 	return find[Deployment](ctx, d.db, "project_id = ?", projectID)
 }
@@ -236,10 +406,113 @@ func (d *DB) UpdateDeployment(ctx context.Context, id uuid.UUID, dep Deployment)
 	return update[Deployment](ctx, d.db, "id = ?", dep, id)
 }
 
+func (d *DB) GetDeploymentEnv(ctx context.Context, id uuid.UUID) (uuid.UUID, map[string]string, error) {
+	deployment, err := gorm.G[Deployment](d.db).Select("id", "project_id", "env").Where("id = ?", id).First(ctx)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	return deployment.ProjectID, deployment.Env.Data(), nil
+}
+
+func (d *DB) VerifyBuildToken(ctx context.Context, id uuid.UUID, token string) error {
+	if token == "" {
+		return gorm.ErrRecordNotFound
+	}
+	_, err := first[Deployment](ctx, d.db, "id = ? AND build_token_hash = ?", id, security.HashToken(token))
+	return err
+}
+
+func (d *DB) ClearBuildToken(ctx context.Context, id uuid.UUID) error {
+	_, err := gorm.G[Deployment](d.db).Where("id = ?", id).Update(ctx, "build_token_hash", "")
+	return err
+}
+
+func (d *DB) GetSuccessfulDeployments(ctx context.Context, projectID uuid.UUID, ids []uuid.UUID) ([]Deployment, error) {
+	return find[Deployment](ctx, d.db, "project_id = ? AND status = ? AND target = ? AND id IN ?", projectID, "SUCCESS", TargetProduction, ids)
+}
+
+func (d *DB) SetDeploymentProtection(ctx context.Context, id uuid.UUID, protection security.Protection) error {
+	_, err := gorm.G[Deployment](d.db).Where("id = ?", id).Update(ctx, "protection", datatypes.NewJSONType(protection))
+	return err
+}
+
+func (d *DB) GetActiveDeployment(ctx context.Context, project Project) (Deployment, error) {
+	if project.ActiveDeploymentID == nil {
+		return Deployment{}, gorm.ErrRecordNotFound
+	}
+	return first[Deployment](ctx, d.db, "id = ? AND status = ?", *project.ActiveDeploymentID, "SUCCESS")
+}
+
+func (d *DB) GetPreviousSuccessfulDeployment(ctx context.Context, projectID uuid.UUID, sequence int) (Deployment, error) {
+	return gorm.G[Deployment](d.db).
+		Where("project_id = ? AND status = ? AND target = ? AND sequence < ?", projectID, "SUCCESS", TargetProduction, sequence).
+		Order("sequence DESC").
+		First(ctx)
+}
+
+func (d *DB) SetActiveDeployment(ctx context.Context, projectID uuid.UUID, deploymentID uuid.UUID) error {
+	return setActiveDeployment(ctx, d.db, projectID, deploymentID)
+}
+
+func setActiveDeployment(ctx context.Context, db *gorm.DB, projectID uuid.UUID, deploymentID uuid.UUID) error {
+	_, err := gorm.G[Project](db).Where("id = ?", projectID).Update(ctx, "active_deployment_id", deploymentID)
+	return err
+}
+
+func (d *DB) CompleteDeployment(ctx context.Context, id uuid.UUID) (Project, error) {
+	var project Project
+
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deployment, err := first[Deployment](ctx, tx, "id = ?", id)
+		if err != nil {
+			return err
+		}
+
+		if err := update(ctx, tx, "id = ?", Deployment{Status: "SUCCESS"}, id); err != nil {
+			return err
+		}
+
+		if deployment.Target != TargetPreview {
+			if err := setActiveDeployment(ctx, tx, deployment.ProjectID, id); err != nil {
+				return err
+			}
+		}
+
+		project, err = first[Project](ctx, tx, "id = ?", deployment.ProjectID)
+		return err
+	})
+
+	return project, err
+}
+
 func (d *DB) DeleteDeployment(ctx context.Context, id uuid.UUID) error {
 	return deleteBy[Deployment](ctx, d.db, "id = ?", id)
 }
 
+func (d *DB) CreateEnvVar(ctx context.Context, v *EnvVar) error {
+	return create(ctx, d.db, v)
+}
+
+func (d *DB) GetEnvVar(ctx context.Context, id uuid.UUID) (EnvVar, error) {
+	return first[EnvVar](ctx, d.db, "id = ?", id)
+}
+
+func (d *DB) GetEnvVars(ctx context.Context, projectID uuid.UUID) ([]EnvVar, error) {
+	return gorm.G[EnvVar](d.db).Where("project_id = ?", projectID).Order("key ASC, target ASC").Find(ctx)
+}
+
+func (d *DB) GetEnvVarsForTarget(ctx context.Context, projectID uuid.UUID, target string) ([]EnvVar, error) {
+	return find[EnvVar](ctx, d.db, "project_id = ? AND target = ?", projectID, target)
+}
+
+func (d *DB) UpdateEnvVar(ctx context.Context, id uuid.UUID, v EnvVar) error {
+	return update[EnvVar](ctx, d.db, "id = ?", v, id)
+}
+
+func (d *DB) DeleteEnvVar(ctx context.Context, id uuid.UUID) error {
+	return deleteBy[EnvVar](ctx, d.db, "id = ?", id)
+}
+
 func (d *DB) CreateLogEvents(ctx context.Context, logs *[]LogEvent) error {
 	return gorm.G[LogEvent](d.db).CreateInBatches(ctx, logs, 10)
 }
@@ -3,6 +3,10 @@ package db
 import (
 	"time"
 
+	"github.com/chrollo-lucifer-12/shared/caching"
+	"github.com/chrollo-lucifer-12/shared/framework"
+	"github.com/chrollo-lucifer-12/shared/security"
+	"github.com/chrollo-lucifer-12/shared/traffic"
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
@@ -48,22 +52,63 @@ type Session struct {
 	ExpiresAt    time.Time `json:"expires_at"`
 }
 
+const (
+	ServingModeStatic    = "static"
+	ServingModeSPA       = "spa"
+	ServingModeCleanURLs = "clean_urls"
+)
+
 type Project struct {
 	Base
-	Name         string       `json:"name"`
-	GitUrl       string       `json:"git_url"`
-	SubDomain    string       `json:"sub_domain"`
-	CustomDomain string       `json:"custom_domain"`
-	UserID       uuid.UUID    `json:"user_id"`
-	Deployments  []Deployment `gorm:"foreignKey:ProjectID" json:"deployments,omitempty"`
+	Name               string                                    `json:"name"`
+	GitUrl             string                                    `json:"git_url"`
+	SubDomain          string                                    `json:"sub_domain"`
+	ActiveDeploymentID *uuid.UUID                                `gorm:"type:uuid" json:"active_deployment_id"`
+	ServingMode        string                                    `gorm:"not null;default:static" json:"serving_mode"`
+	HeaderPolicy       datatypes.JSONType[security.HeaderPolicy] `gorm:"type:jsonb;not null;default:'{}'" json:"header_policy"`
+	Protection         datatypes.JSONType[security.Protection]   `gorm:"type:jsonb;not null;default:'{}'" json:"-"`
+	Firewall           datatypes.JSONType[security.Firewall]     `gorm:"type:jsonb;not null;default:'{}'" json:"firewall"`
+	CachePolicy        datatypes.JSONType[caching.Policy]        `gorm:"type:jsonb;not null;default:'{}'" json:"cache_policy"`
+	TrafficSplit       datatypes.JSONType[traffic.Split]         `gorm:"type:jsonb;not null;default:'{}'" json:"traffic_split"`
+	Framework          string                                    `gorm:"not null;default:''" json:"framework"`
+	BuildSettings      datatypes.JSONType[framework.Settings]    `gorm:"type:jsonb;not null;default:'{}'" json:"build_settings"`
+	UserID             uuid.UUID                                 `json:"user_id"`
+	Deployments        []Deployment                              `gorm:"foreignKey:ProjectID" json:"deployments,omitempty"`
 }
 
 type Deployment struct {
 	Base
-	ProjectID uuid.UUID  `gorm:"type:uuid;index" json:"project_id"`
-	Status    string     `json:"status"`
-	LogEvents []LogEvent `gorm:"foreignKey:DeploymentID" json:"log_events,omitempty"`
-	Sequence  int        `gorm:"autoIncrement" json:"sequence"`
+	ProjectID      uuid.UUID                               `gorm:"type:uuid;index" json:"project_id"`
+	Status         string                                  `json:"status"`
+	LogEvents      []LogEvent                              `gorm:"foreignKey:DeploymentID" json:"log_events,omitempty"`
+	Sequence       int                                     `gorm:"autoIncrement" json:"sequence"`
+	Routes         datatypes.JSON                          `gorm:"type:jsonb" json:"routes,omitempty"`
+	Protection     datatypes.JSONType[security.Protection] `gorm:"type:jsonb;not null;default:'{}'" json:"-"`
+	Functions      datatypes.JSONSlice[string]             `gorm:"type:jsonb;not null;default:'[]'" json:"functions,omitempty"`
+	Target         string                                  `gorm:"not null;default:production" json:"target"`
+	Env            datatypes.JSONType[map[string]string]   `gorm:"type:jsonb;not null;default:'{}'" json:"-"`
+	PackageManager string                                  `json:"package_manager,omitempty"`
+	Framework      string                                  `gorm:"not null;default:''" json:"framework"`
+	BuildSettings  datatypes.JSONType[framework.Settings]  `gorm:"type:jsonb;not null;default:'{}'" json:"build_settings"`
+	BuildTokenHash string                                  `gorm:"not null;default:''" json:"-"`
+}
+
+const (
+	TargetProduction = "production"
+	TargetPreview    = "preview"
+)
+
+func ValidTarget(target string) bool {
+	return target == TargetProduction || target == TargetPreview
+}
+
+type EnvVar struct {
+	Base
+	ProjectID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_env_var" json:"project_id"`
+	Key       string    `gorm:"not null;uniqueIndex:idx_env_var" json:"key"`
+	Target    string    `gorm:"not null;uniqueIndex:idx_env_var" json:"target"`
+	Value     string    `gorm:"not null" json:"-"`
+	Hint      string    `json:"hint"`
 }
 
 type LogEvent struct {
@@ -88,6 +133,27 @@ type WebsiteAnalytics struct {
 	UserAgent      string         `gorm:"type:text" json:"user_agent"`
 	IPAddress      string         `gorm:"type:varchar(45)" json:"ip_address"`
 	Referer        string         `gorm:"type:text" json:"referer"`
+	DeploymentID   *uuid.UUID     `gorm:"type:uuid;index" json:"deployment_id,omitempty"`
+}
+
+type Domain struct {
+	Base
+	ProjectID         uuid.UUID  `gorm:"type:uuid;index;not null" json:"project_id"`
+	Name              string     `gorm:"index;uniqueIndex:idx_domains_verified_name,where:verified;not null" json:"name"`
+	VerificationToken string     `gorm:"not null" json:"verification_token"`
+	Verified          bool       `gorm:"not null;default:false" json:"verified"`
+	VerifiedAt        *time.Time `json:"verified_at"`
+	LastCheckedAt     *time.Time `json:"last_checked_at"`
+	UnverifiedSince   *time.Time `json:"unverified_since"`
+	LastError         string     `json:"last_error"`
+}
+
+type Certificate struct {
+	Key       string     `gorm:"primaryKey" json:"key"`
+	Data      []byte     `json:"-"`
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 type Cache struct {
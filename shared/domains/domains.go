@@ -0,0 +1,173 @@
+package domains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/chrollo-lucifer-12/shared/db"
+	"gorm.io/gorm"
+)
+
+const (
+	ChallengePrefix = "_vercel-verify."
+	challengeValue  = "vercel-verify="
+
+	RecheckInterval    = 24 * time.Hour
+	VerificationWindow = 7 * 24 * time.Hour
+	retryBackoff       = 5 * time.Minute
+)
+
+var ErrNotVerified = errors.New("domain ownership could not be verified")
+
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+}
+
+func NewResolver(addr string) Resolver {
+	if addr == "" {
+		return net.DefaultResolver
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			d := net.Dialer{Timeout: 5 * time.Second}
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+func Normalize(domain string) (string, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+
+	if len(domain) == 0 || len(domain) > 253 || !strings.Contains(domain, ".") {
+		return "", fmt.Errorf("invalid domain %q", domain)
+	}
+
+	for _, label := range strings.Split(domain, ".") {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return "", fmt.Errorf("invalid domain %q", domain)
+		}
+		for _, c := range label {
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+				return "", fmt.Errorf("invalid domain %q", domain)
+			}
+		}
+	}
+
+	return domain, nil
+}
+
+func ChallengeName(domain string) string {
+	return ChallengePrefix + domain
+}
+
+func ChallengeValue(token string) string {
+	return challengeValue + token
+}
+
+type Verifier struct {
+	resolver   Resolver
+	baseDomain string
+}
+
+func NewVerifier(resolver Resolver, baseDomain string) *Verifier {
+	return &Verifier{
+		resolver:   resolver,
+		baseDomain: strings.TrimSuffix(strings.ToLower(baseDomain), "."),
+	}
+}
+
+func (v *Verifier) BaseDomain() string {
+	return v.baseDomain
+}
+
+func (v *Verifier) Target(subdomain string) string {
+	if v.baseDomain == "" {
+		return ""
+	}
+	return subdomain + "." + v.baseDomain
+}
+
+func (v *Verifier) Verify(ctx context.Context, domain, token, subdomain string) error {
+	records, txtErr := v.resolver.LookupTXT(ctx, ChallengeName(domain))
+	for _, record := range records {
+		if strings.TrimSpace(record) == ChallengeValue(token) {
+			return nil
+		}
+	}
+
+	if target := v.Target(subdomain); target != "" {
+		cname, err := v.resolver.LookupCNAME(ctx, domain)
+		if err == nil && strings.TrimSuffix(strings.ToLower(cname), ".") == target {
+			return nil
+		}
+	}
+
+	if txtErr != nil {
+		var dnsErr *net.DNSError
+		if errors.As(txtErr, &dnsErr) && !dnsErr.IsNotFound {
+			return fmt.Errorf("lookup %s: %w", ChallengeName(domain), txtErr)
+		}
+	}
+
+	return ErrNotVerified
+}
+
+func (v *Verifier) Check(ctx context.Context, d *db.DB, domain db.Domain) (bool, error) {
+	project, err := d.GetProjectByID(ctx, domain.ProjectID)
+	if err != nil {
+		return domain.Verified, err
+	}
+
+	err = v.Verify(ctx, domain.Name, domain.VerificationToken, project.SubDomain)
+	verified := err == nil || (domain.Verified && !errors.Is(err, ErrNotVerified))
+
+	checkErr := ""
+	if err != nil {
+		checkErr = err.Error()
+	}
+
+	recordErr := d.RecordDomainCheck(ctx, domain.ID, verified, checkErr)
+	if verified && errors.Is(recordErr, gorm.ErrDuplicatedKey) {
+		verified = false
+		err = fmt.Errorf("%s is already in use by another project", domain.Name)
+		recordErr = d.RecordDomainCheck(ctx, domain.ID, false, err.Error())
+	}
+	if recordErr != nil {
+		return domain.Verified, recordErr
+	}
+
+	return verified, err
+}
+
+func unverifiedSince(domain db.Domain) time.Time {
+	if domain.UnverifiedSince != nil {
+		return *domain.UnverifiedSince
+	}
+	return domain.CreatedAt
+}
+
+func Expired(domain db.Domain, now time.Time) bool {
+	return !domain.Verified && now.Sub(unverifiedSince(domain)) > VerificationWindow
+}
+
+func CheckDue(domain db.Domain, now time.Time) bool {
+	if domain.LastCheckedAt == nil {
+		return !Expired(domain, now)
+	}
+	if domain.Verified {
+		return now.Sub(*domain.LastCheckedAt) >= RecheckInterval
+	}
+	if Expired(domain, now) {
+		return false
+	}
+
+	wait := min(max(domain.LastCheckedAt.Sub(unverifiedSince(domain)), retryBackoff), RecheckInterval)
+	return now.Sub(*domain.LastCheckedAt) >= wait
+}
@@ -0,0 +1,149 @@
+package domains
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrollo-lucifer-12/shared/db"
+	"golang.org/x/net/dns/dnsmessage"
+	"gotest.tools/v3/assert"
+)
+
+type stubZone struct {
+	txt   map[string][]string
+	cname map[string]string
+}
+
+func startStubDNS(t *testing.T, zone stubZone) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			var msg dnsmessage.Message
+			if err := msg.Unpack(buf[:n]); err != nil || len(msg.Questions) == 0 {
+				continue
+			}
+
+			q := msg.Questions[0]
+			name := strings.TrimSuffix(strings.ToLower(q.Name.String()), ".")
+
+			msg.Header.Response = true
+			msg.Header.Authoritative = true
+			msg.Answers = nil
+
+			header := dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60}
+
+			switch q.Type {
+			case dnsmessage.TypeTXT:
+				if values, ok := zone.txt[name]; ok {
+					header.Type = dnsmessage.TypeTXT
+					msg.Answers = append(msg.Answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.TXTResource{TXT: values}})
+				}
+			case dnsmessage.TypeCNAME, dnsmessage.TypeA, dnsmessage.TypeAAAA:
+				if target, ok := zone.cname[name]; ok {
+					header.Type = dnsmessage.TypeCNAME
+					msg.Answers = append(msg.Answers, dnsmessage.Resource{
+						Header: header,
+						Body:   &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName(target + ".")},
+					})
+				}
+			}
+
+			if len(msg.Answers) == 0 {
+				msg.Header.RCode = dnsmessage.RCodeNameError
+			}
+
+			out, err := msg.Pack()
+			if err != nil {
+				continue
+			}
+			conn.WriteTo(out, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestDomainVerification(t *testing.T) {
+	ctx := context.Background()
+
+	addr := startStubDNS(t, stubZone{
+		txt: map[string][]string{
+			"_vercel-verify.txt.example.com": {ChallengeValue("token-1")},
+			"_vercel-verify.wrong.example":   {ChallengeValue("other")},
+		},
+		cname: map[string]string{
+			"www.cname.example": "brave-fox.vercel.test",
+		},
+	})
+
+	verifier := NewVerifier(NewResolver(addr), "vercel.test")
+
+	assert.NilError(t, verifier.Verify(ctx, "txt.example.com", "token-1", "brave-fox"))
+	assert.NilError(t, verifier.Verify(ctx, "www.cname.example", "unused", "brave-fox"))
+
+	assert.ErrorIs(t, verifier.Verify(ctx, "wrong.example", "token-1", "brave-fox"), ErrNotVerified)
+	assert.ErrorIs(t, verifier.Verify(ctx, "missing.example", "token-1", "brave-fox"), ErrNotVerified)
+	assert.ErrorIs(t, verifier.Verify(ctx, "www.cname.example", "unused", "other-project"), ErrNotVerified)
+}
+
+func TestDomainNormalize(t *testing.T) {
+	name, err := Normalize(" WWW.Example.COM. ")
+	assert.NilError(t, err)
+	assert.Equal(t, name, "www.example.com")
+
+	for _, invalid := range []string{"", "localhost", "-bad.example", "bad_label.example", "a..b"} {
+		_, err := Normalize(invalid)
+		assert.Assert(t, err != nil, invalid)
+	}
+}
+
+func TestCheckDue(t *testing.T) {
+	now := time.Now()
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+
+	fresh := db.Domain{}
+	fresh.CreatedAt = now.Add(-time.Minute)
+	assert.Assert(t, CheckDue(fresh, now))
+
+	fresh.LastCheckedAt = ago(time.Minute)
+	fresh.UnverifiedSince = ago(2 * time.Minute)
+	assert.Assert(t, !CheckDue(fresh, now))
+	fresh.LastCheckedAt = ago(retryBackoff)
+	assert.Assert(t, CheckDue(fresh, now))
+
+	failing := db.Domain{UnverifiedSince: ago(3 * time.Hour), LastCheckedAt: ago(time.Hour)}
+	assert.Assert(t, !CheckDue(failing, now))
+	failing.LastCheckedAt = ago(90 * time.Minute)
+	assert.Assert(t, CheckDue(failing, now))
+
+	slow := db.Domain{UnverifiedSince: ago(5 * 24 * time.Hour), LastCheckedAt: ago(23 * time.Hour)}
+	assert.Assert(t, !CheckDue(slow, now))
+	slow.LastCheckedAt = ago(RecheckInterval)
+	assert.Assert(t, CheckDue(slow, now))
+
+	expired := db.Domain{UnverifiedSince: ago(VerificationWindow + time.Hour), LastCheckedAt: ago(48 * time.Hour)}
+	assert.Assert(t, Expired(expired, now))
+	assert.Assert(t, !CheckDue(expired, now))
+
+	verified := db.Domain{Verified: true, LastCheckedAt: ago(time.Hour)}
+	verified.CreatedAt = now.Add(-30 * 24 * time.Hour)
+	assert.Assert(t, !Expired(verified, now))
+	assert.Assert(t, !CheckDue(verified, now))
+	verified.LastCheckedAt = ago(RecheckInterval)
+	assert.Assert(t, CheckDue(verified, now))
+}
@@ -2,6 +2,8 @@ package env
 
 import (
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -12,6 +14,11 @@ func (key EnvKey) GetValue() string {
 	return os.Getenv(string(key))
 }
 
+func (key EnvKey) GetInt64() int64 {
+	value, _ := strconv.ParseInt(key.GetValue(), 10, 64)
+	return value
+}
+
 const (
 	Env                  EnvKey = "ENV"
 	GithubToken          EnvKey = "GITHUB_TOKEN"
@@ -24,8 +31,73 @@ const (
 	SupabaseEndpoint     EnvKey = "SUPABASE_ENDPOINT"
 	ResendApiKey         EnvKey = "RESEND_API_KEY"
 	RedisUrl             EnvKey = "REDIS_URL"
+	BaseDomain           EnvKey = "BASE_DOMAIN"
+	PathRouting          EnvKey = "PATH_ROUTING"
+	CacheMemoryBytes     EnvKey = "CACHE_MEMORY_BYTES"
+	CacheMaxObjectBytes  EnvKey = "CACHE_MAX_OBJECT_BYTES"
+	MetricsPort          EnvKey = "METRICS_PORT"
+	ProtectionSecret     EnvKey = "PROTECTION_SECRET"
+	TrustProxyHeaders    EnvKey = "TRUST_PROXY_HEADERS"
+	RateLimitRPS         EnvKey = "RATE_LIMIT_RPS"
+	RateLimitBurst       EnvKey = "RATE_LIMIT_BURST"
+	TLSPort              EnvKey = "TLS_PORT"
+	TLSCertFile          EnvKey = "TLS_CERT_FILE"
+	TLSKeyFile           EnvKey = "TLS_KEY_FILE"
+	AcmeDirectoryURL     EnvKey = "ACME_DIRECTORY_URL"
+	AcmeCABundle         EnvKey = "ACME_CA_BUNDLE"
+	AcmeEmail            EnvKey = "ACME_EMAIL"
+	AcmeRenewBeforeHours EnvKey = "ACME_RENEW_BEFORE_HOURS"
+	DnsResolverAddr      EnvKey = "DNS_RESOLVER_ADDR"
+	FunctionTimeoutMs    EnvKey = "FUNCTION_TIMEOUT_MS"
+	FunctionMemoryMB     EnvKey = "FUNCTION_MEMORY_MB"
+	FunctionConcurrency  EnvKey = "FUNCTION_CONCURRENCY"
+	FunctionMaxBodyBytes EnvKey = "FUNCTION_MAX_BODY_BYTES"
+	ImageWidths          EnvKey = "IMAGE_WIDTHS"
+	ImageMaxSourceBytes  EnvKey = "IMAGE_MAX_SOURCE_BYTES"
+	EnvEncryptionKey     EnvKey = "ENV_ENCRYPTION_KEY"
+	BuildRunner          EnvKey = "BUILD_RUNNER"
+	BuildServerBinary    EnvKey = "BUILD_SERVER_BINARY"
+	BuildWorkDir         EnvKey = "BUILD_WORK_DIR"
+	BuildSourceRoot      EnvKey = "BUILD_SOURCE_ROOT"
+	BuildDockerSocket    EnvKey = "BUILD_DOCKER_SOCKET"
+	BuildDockerImage     EnvKey = "BUILD_DOCKER_IMAGE"
+	BuildDockerNetwork   EnvKey = "BUILD_DOCKER_NETWORK"
+	BuildGithubOwner     EnvKey = "BUILD_GITHUB_OWNER"
+	BuildGithubRepo      EnvKey = "BUILD_GITHUB_REPO"
+	BuildGithubWorkflow  EnvKey = "BUILD_GITHUB_WORKFLOW"
+	BuildGithubRef       EnvKey = "BUILD_GITHUB_REF"
+	BuildSecretsKey      EnvKey = "BUILD_SECRETS_KEY"
 )
 
+var reservedNames = map[string]bool{
+	"PATH":                true,
+	"HOME":                true,
+	"NODE_OPTIONS":        true,
+	"LD_PRELOAD":          true,
+	"LD_LIBRARY_PATH":     true,
+	"DSN":                 true,
+	"REDIS_URL":           true,
+	"ENV_ENCRYPTION_KEY":  true,
+	"DEPLOYMENT_ID":       true,
+	"SLUG":                true,
+	"GIT_REPOSITORY_URL":  true,
+	"BUCKET_ID":           true,
+	"FRAMEWORK":           true,
+	"BUILD_SETTINGS":      true,
+	"BUILD_SECRETS_FILE":  true,
+	"BUILD_SECRETS_KEY":   true,
+	"WORK_DIR":            true,
+	"REGION":              true,
+	"SUPABASE_ENDPOINT":   true,
+	"SUPABASE_ACCESS_KEY": true,
+	"SUPABASE_SECRET_KEY": true,
+	"COMPRESS_ASSETS":     true,
+}
+
+func Reserved(name string) bool {
+	return reservedNames[strings.ToUpper(name)]
+}
+
 const (
 	EnvDevelopment = "development"
 	EnvProduction  = "production"
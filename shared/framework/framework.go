@@ -0,0 +1,217 @@
+package framework
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	Auto           = ""
+	NextJS         = "nextjs"
+	Astro          = "astro"
+	SvelteKit      = "sveltekit"
+	CreateReactApp = "create-react-app"
+	Vite           = "vite"
+	Node           = "node"
+	Hugo           = "hugo"
+	Static         = "static"
+)
+
+type Framework struct {
+	Name           string
+	Install        bool
+	InstallCommand []string
+	Script         string
+	Command        []string
+	OutputDir      string
+}
+
+type definition struct {
+	name         string
+	dependencies []string
+	outputDir    string
+}
+
+var nodeFrameworks = []definition{
+	{NextJS, []string{"next"}, "out"},
+	{Astro, []string{"astro"}, "dist"},
+	{SvelteKit, []string{"@sveltejs/kit"}, "build"},
+	{CreateReactApp, []string{"react-scripts"}, "build"},
+	{Vite, []string{"vite"}, "dist"},
+}
+
+var outputCandidates = []string{"dist", "build", "out", "public"}
+
+var hugoConfigs = []string{"hugo.toml", "hugo.yaml", "hugo.json", "config.toml", "config.yaml"}
+
+type PackageJSON struct {
+	PackageManager  string            `json:"packageManager"`
+	Scripts         map[string]string `json:"scripts"`
+	Dependencies    map[string]string `json:"dependencies"`
+	DevDependencies map[string]string `json:"devDependencies"`
+}
+
+func (p *PackageJSON) Has(dependency string) bool {
+	if _, ok := p.Dependencies[dependency]; ok {
+		return true
+	}
+	_, ok := p.DevDependencies[dependency]
+	return ok
+}
+
+func ReadPackageJSON(dir string) (*PackageJSON, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg PackageJSON
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("invalid package.json: %w", err)
+	}
+	return &pkg, nil
+}
+
+func Names() []string {
+	names := make([]string, 0, len(nodeFrameworks)+3)
+	for _, def := range nodeFrameworks {
+		names = append(names, def.name)
+	}
+	return append(names, Node, Hugo, Static)
+}
+
+func Valid(name string) bool {
+	if name == Auto {
+		return true
+	}
+	for _, known := range Names() {
+		if name == known {
+			return true
+		}
+	}
+	return false
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func isHugo(dir string) bool {
+	for _, name := range hugoConfigs {
+		if !exists(filepath.Join(dir, name)) {
+			continue
+		}
+		if strings.HasPrefix(name, "hugo.") || exists(filepath.Join(dir, "content")) || exists(filepath.Join(dir, "layouts")) {
+			return true
+		}
+	}
+	return false
+}
+
+func nodeFramework(name string, pkg *PackageJSON) Framework {
+	fw := Framework{Name: name, Install: true}
+	for _, def := range nodeFrameworks {
+		if def.name == name {
+			fw.OutputDir = def.outputDir
+		}
+	}
+	if pkg != nil {
+		if _, ok := pkg.Scripts["build"]; ok {
+			fw.Script = "build"
+		}
+	}
+	return fw
+}
+
+func staticFramework(dir string) Framework {
+	if !exists(filepath.Join(dir, "index.html")) && exists(filepath.Join(dir, "public", "index.html")) {
+		return Framework{Name: Static, OutputDir: "public"}
+	}
+	return Framework{Name: Static, OutputDir: "."}
+}
+
+func Detect(dir, override string) (Framework, error) {
+	if !Valid(override) {
+		return Framework{}, fmt.Errorf("unknown framework %q", override)
+	}
+
+	pkg, err := ReadPackageJSON(dir)
+	if err != nil {
+		return Framework{}, err
+	}
+
+	switch override {
+	case Hugo:
+		return Framework{Name: Hugo, Command: []string{"hugo", "--minify"}, OutputDir: "public"}, nil
+	case Static:
+		return staticFramework(dir), nil
+	case Auto:
+	default:
+		return nodeFramework(override, pkg), nil
+	}
+
+	if pkg != nil {
+		for _, def := range nodeFrameworks {
+			for _, dependency := range def.dependencies {
+				if pkg.Has(dependency) {
+					return nodeFramework(def.name, pkg), nil
+				}
+			}
+		}
+
+		return nodeFramework(Node, pkg), nil
+	}
+
+	if isHugo(dir) {
+		return Detect(dir, Hugo)
+	}
+
+	return staticFramework(dir), nil
+}
+
+func (f Framework) Output(dir string) (string, error) {
+	if f.OutputDir != "" {
+		path := filepath.Join(dir, f.OutputDir)
+		if !exists(path) {
+			return "", fmt.Errorf("output directory %s was not created by the build", f.OutputDir)
+		}
+		return path, nil
+	}
+
+	for _, candidate := range outputCandidates {
+		if path := filepath.Join(dir, candidate); exists(path) {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no output directory found, looked for %s", strings.Join(outputCandidates, ", "))
+}
+
+func (f Framework) String() string {
+	var steps []string
+	if len(f.InstallCommand) > 0 {
+		steps = append(steps, "run "+strings.Join(f.InstallCommand, " "))
+	} else if f.Install {
+		steps = append(steps, "install dependencies")
+	}
+	if f.Script != "" {
+		steps = append(steps, "run script "+f.Script)
+	}
+	if len(f.Command) > 0 {
+		steps = append(steps, "run "+strings.Join(f.Command, " "))
+	}
+	if len(steps) == 0 {
+		steps = append(steps, "no build step")
+	}
+	output := f.OutputDir
+	if output == "" {
+		output = "auto"
+	}
+	return fmt.Sprintf("%s (%s, output %s)", f.Name, strings.Join(steps, ", "), output)
+}
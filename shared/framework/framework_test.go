@@ -0,0 +1,112 @@
+package framework
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NilError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NilError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestDetectNodeFrameworks(t *testing.T) {
+	for _, tc := range []struct {
+		pkg    string
+		name   string
+		output string
+		script string
+	}{
+		{`{"dependencies":{"next":"14"},"scripts":{"build":"next build"}}`, NextJS, "out", "build"},
+		{`{"devDependencies":{"astro":"4","vite":"5"}}`, Astro, "dist", ""},
+		{`{"devDependencies":{"@sveltejs/kit":"2","vite":"5"},"scripts":{"build":"vite build"}}`, SvelteKit, "build", "build"},
+		{`{"dependencies":{"react-scripts":"5"},"scripts":{"build":"react-scripts build"}}`, CreateReactApp, "build", "build"},
+		{`{"devDependencies":{"vite":"5"},"scripts":{"build":"vite build"}}`, Vite, "dist", "build"},
+		{`{"scripts":{"build":"node build.js"}}`, Node, "", "build"},
+	} {
+		dir := writeFiles(t, map[string]string{"package.json": tc.pkg})
+
+		fw, err := Detect(dir, Auto)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, fw, Framework{Name: tc.name, Install: true, Script: tc.script, OutputDir: tc.output})
+	}
+}
+
+func TestDetectHugo(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"hugo.toml": "", "content/_index.md": ""})
+
+	fw, err := Detect(dir, Auto)
+	assert.NilError(t, err)
+	assert.Equal(t, fw.Name, Hugo)
+	assert.DeepEqual(t, fw.Command, []string{"hugo", "--minify"})
+	assert.Equal(t, fw.OutputDir, "public")
+
+	dir = writeFiles(t, map[string]string{"config.toml": "", "layouts/index.html": ""})
+	fw, err = Detect(dir, Auto)
+	assert.NilError(t, err)
+	assert.Equal(t, fw.Name, Hugo)
+
+	dir = writeFiles(t, map[string]string{"config.toml": "", "index.html": ""})
+	fw, err = Detect(dir, Auto)
+	assert.NilError(t, err)
+	assert.Equal(t, fw.Name, Static)
+}
+
+func TestDetectStatic(t *testing.T) {
+	fw, err := Detect(writeFiles(t, map[string]string{"index.html": ""}), Auto)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, fw, Framework{Name: Static, OutputDir: "."})
+
+	fw, err = Detect(writeFiles(t, map[string]string{"public/index.html": ""}), Auto)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, fw, Framework{Name: Static, OutputDir: "public"})
+}
+
+func TestDetectOverride(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"package.json": `{"dependencies":{"next":"14"}}`})
+
+	fw, err := Detect(dir, Vite)
+	assert.NilError(t, err)
+	assert.Equal(t, fw.Name, Vite)
+	assert.Equal(t, fw.OutputDir, "dist")
+
+	fw, err = Detect(dir, Static)
+	assert.NilError(t, err)
+	assert.Equal(t, fw.Name, Static)
+
+	_, err = Detect(dir, "rails")
+	assert.ErrorContains(t, err, "unknown framework")
+}
+
+func TestDetectInvalidPackageJSON(t *testing.T) {
+	_, err := Detect(writeFiles(t, map[string]string{"package.json": "{"}), Auto)
+	assert.ErrorContains(t, err, "invalid package.json")
+}
+
+func TestOutput(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"build/index.html": ""})
+
+	path, err := Framework{}.Output(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, path, filepath.Join(dir, "build"))
+
+	_, err = Framework{OutputDir: "dist"}.Output(dir)
+	assert.ErrorContains(t, err, "was not created")
+
+	_, err = Framework{}.Output(t.TempDir())
+	assert.ErrorContains(t, err, "no output directory")
+}
+
+func TestString(t *testing.T) {
+	assert.Equal(t, Framework{Name: Vite, Install: true, Script: "build", OutputDir: "dist"}.String(), "vite (install dependencies, run script build, output dist)")
+	assert.Equal(t, Framework{Name: Static, OutputDir: "."}.String(), "static (no build step, output .)")
+	assert.Equal(t, Framework{Name: Node, InstallCommand: []string{"npm", "ci"}}.String(), "node (run npm ci, output auto)")
+}
@@ -0,0 +1,109 @@
+package framework
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const maxCommandLength = 1024
+
+var nodeVersionPattern = regexp.MustCompile(`^(lts|[0-9]{1,2}(\.[0-9]{1,3}){0,2})$`)
+
+type Settings struct {
+	InstallCommand  string `json:"install_command,omitempty"`
+	BuildCommand    string `json:"build_command,omitempty"`
+	OutputDirectory string `json:"output_directory,omitempty"`
+	RootDirectory   string `json:"root_directory,omitempty"`
+	NodeVersion     string `json:"node_version,omitempty"`
+}
+
+func validCommand(command string) error {
+	if len(command) > maxCommandLength {
+		return fmt.Errorf("longer than %d characters", maxCommandLength)
+	}
+	if strings.ContainsAny(command, "\x00\r\n") {
+		return fmt.Errorf("must be a single line")
+	}
+	return nil
+}
+
+func validDirectory(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	if strings.ContainsAny(dir, "\x00\\") {
+		return fmt.Errorf("contains invalid characters")
+	}
+	if path.IsAbs(dir) {
+		return fmt.Errorf("must be relative to the repository")
+	}
+	if clean := path.Clean(dir); clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("must stay inside the repository")
+	}
+	return nil
+}
+
+func (s Settings) Validate() error {
+	if err := validCommand(s.InstallCommand); err != nil {
+		return fmt.Errorf("install_command: %w", err)
+	}
+	if err := validCommand(s.BuildCommand); err != nil {
+		return fmt.Errorf("build_command: %w", err)
+	}
+	if err := validDirectory(s.OutputDirectory); err != nil {
+		return fmt.Errorf("output_directory: %w", err)
+	}
+	if err := validDirectory(s.RootDirectory); err != nil {
+		return fmt.Errorf("root_directory: %w", err)
+	}
+	if s.NodeVersion != "" && !nodeVersionPattern.MatchString(s.NodeVersion) {
+		return fmt.Errorf("node_version: expected a version like 20, 20.11 or lts")
+	}
+	return nil
+}
+
+func (s Settings) Normalize() Settings {
+	s.InstallCommand = strings.TrimSpace(s.InstallCommand)
+	s.BuildCommand = strings.TrimSpace(s.BuildCommand)
+	s.NodeVersion = strings.TrimPrefix(strings.TrimSpace(s.NodeVersion), "v")
+	for _, dir := range []*string{&s.OutputDirectory, &s.RootDirectory} {
+		*dir = strings.TrimSpace(*dir)
+		if *dir != "" {
+			*dir = path.Clean(*dir)
+		}
+		if *dir == "." {
+			*dir = ""
+		}
+	}
+	return s
+}
+
+func ParseSettings(raw string) (Settings, error) {
+	var s Settings
+	if raw == "" {
+		return s, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &s); err != nil {
+		return Settings{}, fmt.Errorf("invalid build settings: %w", err)
+	}
+	s = s.Normalize()
+	return s, s.Validate()
+}
+
+func (s Settings) Apply(fw Framework) Framework {
+	if s.InstallCommand != "" {
+		fw.Install = true
+		fw.InstallCommand = []string{"sh", "-c", s.InstallCommand}
+	}
+	if s.BuildCommand != "" {
+		fw.Script = ""
+		fw.Command = []string{"sh", "-c", s.BuildCommand}
+	}
+	if s.OutputDirectory != "" {
+		fw.OutputDir = s.OutputDirectory
+	}
+	return fw
+}
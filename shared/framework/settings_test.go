@@ -0,0 +1,87 @@
+package framework
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSettingsValidate(t *testing.T) {
+	assert.NilError(t, Settings{}.Validate())
+	assert.NilError(t, Settings{
+		InstallCommand:  "npm ci --omit=dev",
+		BuildCommand:    "npm run build && npm run export",
+		OutputDirectory: "apps/web/dist",
+		RootDirectory:   "apps/web",
+		NodeVersion:     "20.11",
+	}.Validate())
+
+	for _, tc := range []struct {
+		settings Settings
+		message  string
+	}{
+		{Settings{BuildCommand: "npm run build\nrm -rf /"}, "build_command: must be a single line"},
+		{Settings{InstallCommand: strings.Repeat("a", maxCommandLength+1)}, "install_command: longer than"},
+		{Settings{OutputDirectory: "/etc"}, "output_directory: must be relative"},
+		{Settings{RootDirectory: "../other"}, "root_directory: must stay inside"},
+		{Settings{RootDirectory: "apps/../.."}, "root_directory: must stay inside"},
+		{Settings{OutputDirectory: `dist\out`}, "output_directory: contains invalid characters"},
+		{Settings{NodeVersion: "latest"}, "node_version"},
+		{Settings{NodeVersion: "20.1.2.3"}, "node_version"},
+	} {
+		assert.ErrorContains(t, tc.settings.Validate(), tc.message)
+	}
+
+	for _, version := range []string{"lts", "20", "18.19", "20.11.1"} {
+		assert.NilError(t, Settings{NodeVersion: version}.Validate(), version)
+	}
+}
+
+func TestSettingsNormalize(t *testing.T) {
+	s := Settings{
+		InstallCommand:  "  npm ci ",
+		BuildCommand:    " npm run build\t",
+		OutputDirectory: " ./dist/ ",
+		RootDirectory:   ".",
+		NodeVersion:     " v20 ",
+	}.Normalize()
+
+	assert.DeepEqual(t, s, Settings{
+		InstallCommand:  "npm ci",
+		BuildCommand:    "npm run build",
+		OutputDirectory: "dist",
+		NodeVersion:     "20",
+	})
+}
+
+func TestParseSettings(t *testing.T) {
+	s, err := ParseSettings("")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, s, Settings{})
+
+	s, err = ParseSettings(`{"root_directory":"apps/web/","node_version":"v18"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, s, Settings{RootDirectory: "apps/web", NodeVersion: "18"})
+
+	_, err = ParseSettings(`{"root_directory":"../x"}`)
+	assert.ErrorContains(t, err, "root_directory")
+
+	_, err = ParseSettings("{")
+	assert.ErrorContains(t, err, "invalid build settings")
+}
+
+func TestSettingsApply(t *testing.T) {
+	detected := Framework{Name: Vite, Install: true, Script: "build", OutputDir: "dist"}
+
+	assert.DeepEqual(t, Settings{}.Apply(detected), detected)
+
+	fw := Settings{InstallCommand: "pnpm i", BuildCommand: "make site", OutputDirectory: "site"}.Apply(detected)
+	assert.DeepEqual(t, fw, Framework{
+		Name:           Vite,
+		Install:        true,
+		InstallCommand: []string{"sh", "-c", "pnpm i"},
+		Command:        []string{"sh", "-c", "make site"},
+		OutputDir:      "site",
+	})
+}
@@ -0,0 +1,101 @@
+package functions
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	Dir       = "api"
+	Extension = ".wasm"
+
+	objectRoot = "_functions"
+)
+
+type Request struct {
+	Method  string              `json:"method"`
+	Path    string              `json:"path"`
+	Query   string              `json:"query,omitempty"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	Body    []byte              `json:"body,omitempty"`
+}
+
+type Response struct {
+	Status  int                 `json:"status"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	Body    []byte              `json:"body,omitempty"`
+}
+
+type Function struct {
+	Route string
+	File  string
+}
+
+func Route(rel string) string {
+	route := "/" + Dir + "/" + strings.TrimSuffix(filepath.ToSlash(rel), Extension)
+	route = strings.TrimSuffix(route, "/index")
+	return path.Clean(route)
+}
+
+func ObjectKey(prefix, route string) string {
+	return objectRoot + "/" + prefix + route + Extension
+}
+
+func Collect(root string) ([]Function, error) {
+	dir := filepath.Join(root, Dir)
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var found []Function
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(file) != Extension {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+
+		found = append(found, Function{Route: Route(rel), File: file})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].Route < found[j].Route })
+
+	for i := 1; i < len(found); i++ {
+		if found[i].Route == found[i-1].Route {
+			return nil, fmt.Errorf("%s and %s both serve %s", found[i-1].File, found[i].File, found[i].Route)
+		}
+	}
+
+	return found, nil
+}
+
+func Match(routes []string, requestPath string) (string, bool) {
+	if len(routes) == 0 {
+		return "", false
+	}
+
+	requestPath = path.Clean("/" + requestPath)
+	for _, route := range routes {
+		if route == requestPath {
+			return route, true
+		}
+	}
+
+	return "", false
+}
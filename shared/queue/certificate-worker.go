@@ -0,0 +1,145 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/chrollo-lucifer-12/shared/certs"
+	"github.com/chrollo-lucifer-12/shared/db"
+	"github.com/chrollo-lucifer-12/shared/utils"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+const TypeCertificateRenew = "certificates:renew"
+
+type CertificateWorker struct {
+	server     *asynq.Server
+	scheduler  *asynq.Scheduler
+	mux        *asynq.ServeMux
+	db         *db.DB
+	cfg        certs.Config
+	baseDomain string
+}
+
+func NewCertificateWorker(ctx context.Context, dsn string, redisAddr string, baseDomain string, cfg certs.Config) *CertificateWorker {
+	db, _ := db.NewDB(dsn, ctx)
+	opt, _ := asynq.ParseRedisURI(redisAddr)
+	server := asynq.NewServer(
+		opt,
+		asynq.Config{
+			Concurrency: 1,
+			Queues: map[string]int{
+				"certificates": 1,
+			},
+		},
+	)
+
+	worker := &CertificateWorker{
+		server:     server,
+		scheduler:  asynq.NewScheduler(opt, nil),
+		mux:        asynq.NewServeMux(),
+		db:         db,
+		cfg:        cfg,
+		baseDomain: strings.TrimSuffix(strings.ToLower(baseDomain), "."),
+	}
+
+	worker.registerHandlers()
+
+	return worker
+}
+
+func (w *CertificateWorker) hostAllowed(ctx context.Context, host string) (bool, error) {
+	var err error
+
+	if w.baseDomain != "" && strings.HasSuffix(host, "."+w.baseDomain) {
+		label := strings.TrimSuffix(host, "."+w.baseDomain)
+		if label == "" || strings.Contains(label, ".") {
+			return false, nil
+		}
+		if subdomain, _, ok := utils.ParsePreviewSubdomain(label); ok {
+			label = subdomain
+		}
+		_, err = w.db.GetProjectBySlug(ctx, label)
+	} else {
+		_, err = w.db.GetProjectByCustomDomain(ctx, host)
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (w *CertificateWorker) hostPolicy(ctx context.Context, host string) error {
+	allowed, err := w.hostAllowed(ctx, host)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("%s is not a verified custom domain", host)
+	}
+	return nil
+}
+
+func (w *CertificateWorker) registerHandlers() {
+	w.mux.HandleFunc(TypeCertificateRenew, func(ctx context.Context, t *asynq.Task) error {
+		issued, err := w.db.GetIssuedCertificates(ctx)
+		if err != nil {
+			return err
+		}
+
+		cache := certs.NewCache(w.db)
+		renewBefore := time.Now().Add(w.cfg.RenewWindow())
+
+		for _, cert := range issued {
+			allowed, err := w.hostAllowed(ctx, certs.HostName(cert.Key))
+			if err != nil {
+				log.Println("Failed to check certificate host:", err)
+				continue
+			}
+
+			if !allowed {
+				log.Println("Deleting certificate", cert.Key, "for a domain that is no longer verified")
+				if err := cache.Delete(ctx, cert.Key); err != nil {
+					log.Println("Failed to delete certificate:", err)
+				}
+				continue
+			}
+
+			if !cert.ExpiresAt.Before(renewBefore) {
+				continue
+			}
+
+			log.Println("Renewing certificate", cert.Key, "expiring at", cert.ExpiresAt)
+			if err := certs.Renew(ctx, w.cfg, cache, cert.Key, w.hostPolicy); err != nil {
+				log.Println("Failed to renew certificate:", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (w *CertificateWorker) Start() {
+	log.Println("Running certificate worker")
+
+	_, err := w.scheduler.Register("@every 6h", asynq.NewTask(TypeCertificateRenew, nil), asynq.Queue("certificates"), asynq.MaxRetry(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go func() {
+		if err := w.scheduler.Run(); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	if err := w.server.Run(w.mux); err != nil {
+		log.Fatal(err)
+	}
+}
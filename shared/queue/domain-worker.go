@@ -0,0 +1,100 @@
+package queue
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/chrollo-lucifer-12/shared/db"
+	"github.com/chrollo-lucifer-12/shared/domains"
+	"github.com/chrollo-lucifer-12/shared/redis"
+	"github.com/chrollo-lucifer-12/shared/utils"
+	"github.com/hibiken/asynq"
+)
+
+const TypeDomainVerify = "domains:verify"
+
+type DomainWorker struct {
+	server    *asynq.Server
+	scheduler *asynq.Scheduler
+	mux       *asynq.ServeMux
+	db        *db.DB
+	redis     *redis.RedisClient
+	verifier  *domains.Verifier
+}
+
+func NewDomainWorker(ctx context.Context, dsn string, redisAddr string, verifier *domains.Verifier) *DomainWorker {
+	db, _ := db.NewDB(dsn, ctx)
+	opt, _ := asynq.ParseRedisURI(redisAddr)
+	server := asynq.NewServer(
+		opt,
+		asynq.Config{
+			Concurrency: 1,
+			Queues: map[string]int{
+				"domains": 1,
+			},
+		},
+	)
+
+	worker := &DomainWorker{
+		server:    server,
+		scheduler: asynq.NewScheduler(opt, nil),
+		mux:       asynq.NewServeMux(),
+		db:        db,
+		redis:     redis.NewRedisClient(redisAddr),
+		verifier:  verifier,
+	}
+
+	worker.registerHandlers()
+
+	return worker
+}
+
+func (w *DomainWorker) registerHandlers() {
+	w.mux.HandleFunc(TypeDomainVerify, func(ctx context.Context, t *asynq.Task) error {
+		now := time.Now()
+		list, err := w.db.GetDomainsToCheck(ctx, now.Add(-domains.RecheckInterval), now.Add(-domains.VerificationWindow))
+		if err != nil {
+			return err
+		}
+
+		for _, domain := range list {
+			if !domains.CheckDue(domain, now) {
+				continue
+			}
+
+			verified, err := w.verifier.Check(ctx, w.db, domain)
+			if verified == domain.Verified {
+				continue
+			}
+
+			if verified {
+				log.Println("Verified domain", domain.Name)
+			} else {
+				log.Printf("Domain %s is no longer verified: %v", domain.Name, err)
+			}
+			w.redis.Del(ctx, utils.GetDomainCacheKey(domain.Name))
+		}
+
+		return nil
+	})
+}
+
+func (w *DomainWorker) Start() {
+	log.Println("Running domain worker")
+
+	_, err := w.scheduler.Register("@every 5m", asynq.NewTask(TypeDomainVerify, nil), asynq.Queue("domains"), asynq.MaxRetry(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go func() {
+		if err := w.scheduler.Run(); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	if err := w.server.Run(w.mux); err != nil {
+		log.Fatal(err)
+	}
+}
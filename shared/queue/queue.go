@@ -3,11 +3,14 @@ package queue
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/chrollo-lucifer-12/shared/db"
 	"github.com/hibiken/asynq"
 )
 
+const BuildTimeout = 30 * time.Minute
+
 type QueueClient struct {
 	client *asynq.Client
 }
@@ -20,19 +23,23 @@ type EmailJob struct {
 }
 
 type WorkflowJob struct {
-	GithubToken string `json:"githubToken"`
-	Owner       string `json:"owner"`
-	Repo        string `json:"repo"`
-	Workflow    string `json:"workflow"`
-	Ref         string `json:"ref"`
-
-	GitURL       string `json:"gitURL"`
-	ApiURL       string `json:"apiURL"`
-	ApiKey       string `json:"apiKey"`
-	BucketID     string `json:"bucketId"`
-	ProjectSlug  string `json:"projectSlug"`
-	DeploymentID string `json:"deploymentId"`
-	UserEnv      string `json:"userEnv"`
+	GitURL        string `json:"gitURL"`
+	ApiURL        string `json:"apiURL"`
+	ApiKey        string `json:"apiKey"`
+	BucketID      string `json:"bucketId"`
+	ProjectSlug   string `json:"projectSlug"`
+	DeploymentID  string `json:"deploymentId"`
+	Framework     string `json:"framework"`
+	BuildSettings string `json:"buildSettings"`
+}
+
+type BuildResult struct {
+	DeploymentID   string          `json:"deploymentId"`
+	Token          string          `json:"token"`
+	Status         string          `json:"status"`
+	Routes         json.RawMessage `json:"routes,omitempty"`
+	PackageManager string          `json:"packageManager,omitempty"`
+	Functions      []string        `json:"functions,omitempty"`
 }
 
 func NewAsynqClient(redisURL string) *QueueClient {
@@ -83,7 +90,7 @@ func (q *QueueClient) NewWorkflowTask(payload WorkflowJob) (*asynq.Task, error)
 		return nil, fmt.Errorf("failed to marshal workflow payload: %w", err)
 	}
 
-	task := asynq.NewTask("workflow:trigger", data)
+	task := asynq.NewTask(TypeWorkflowTrigger, data)
 
 	fmt.Println("added new workflow task", task)
 
@@ -91,6 +98,7 @@ func (q *QueueClient) NewWorkflowTask(payload WorkflowJob) (*asynq.Task, error)
 		task,
 		asynq.Queue("workflows"),
 		asynq.MaxRetry(1),
+		asynq.Timeout(BuildTimeout),
 	)
 
 	if err != nil {
@@ -99,3 +107,24 @@ func (q *QueueClient) NewWorkflowTask(payload WorkflowJob) (*asynq.Task, error)
 
 	return task, nil
 }
+
+func (q *QueueClient) NewBuildResultTask(result BuildResult) (*asynq.Task, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal build result: %w", err)
+	}
+
+	task := asynq.NewTask(TypeBuildResult, data)
+
+	_, err = q.client.Enqueue(
+		task,
+		asynq.Queue("workflows"),
+		asynq.MaxRetry(5),
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue build result: %w", err)
+	}
+
+	return task, nil
+}
@@ -5,21 +5,33 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"time"
 
+	"github.com/chrollo-lucifer-12/shared/db"
+	"github.com/chrollo-lucifer-12/shared/redis"
+	"github.com/chrollo-lucifer-12/shared/secrets"
+	"github.com/chrollo-lucifer-12/shared/security"
+	"github.com/chrollo-lucifer-12/shared/utils"
 	"github.com/chrollo-lucifer-12/shared/workflow"
+	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 )
 
-const TypeWorkflowTrigger = "workflow:trigger"
+const (
+	TypeWorkflowTrigger = "workflow:trigger"
+	TypeBuildResult     = "workflow:result"
+)
 
 type WorkflowWorker struct {
-	server         *asynq.Server
-	mux            *asynq.ServeMux
-	workflowClient *workflow.WorkflowClient
+	server  *asynq.Server
+	mux     *asynq.ServeMux
+	runner  workflow.BuildRunner
+	db      *db.DB
+	redis   *redis.RedisClient
+	secrets *secrets.Box
 }
 
-func NewWorkflowWorker(ctx context.Context, token string, redisAddr string) *WorkflowWorker {
-	fmt.Println("TOKEN LENGTH:", len(token))
+func NewWorkflowWorker(ctx context.Context, runner workflow.BuildRunner, dsn string, redisAddr string, encryptionKey string) *WorkflowWorker {
 	opt, err := asynq.ParseRedisURI(redisAddr)
 	if err != nil {
 		fmt.Println(err)
@@ -35,14 +47,22 @@ func NewWorkflowWorker(ctx context.Context, token string, redisAddr string) *Wor
 		},
 	)
 
-	workflowClient := workflow.NewWorkflowClient(ctx, token)
+	db, _ := db.NewDB(dsn, ctx)
+
+	box, err := secrets.New(encryptionKey)
+	if err != nil {
+		log.Printf("environment variables are unavailable to builds: %v", err)
+	}
 
 	mux := asynq.NewServeMux()
 
 	worker := &WorkflowWorker{
-		server:         server,
-		mux:            mux,
-		workflowClient: workflowClient,
+		server:  server,
+		mux:     mux,
+		runner:  runner,
+		db:      db,
+		redis:   redis.NewRedisClient(redisAddr),
+		secrets: box,
 	}
 
 	worker.registerHandlers()
@@ -50,6 +70,19 @@ func NewWorkflowWorker(ctx context.Context, token string, redisAddr string) *Wor
 	return worker
 }
 
+func (w *WorkflowWorker) buildEnv(ctx context.Context, deploymentID uuid.UUID) (map[string]string, error) {
+	projectID, encrypted, err := w.db.GetDeploymentEnv(ctx, deploymentID)
+	if err != nil || len(encrypted) == 0 {
+		return nil, err
+	}
+
+	if w.secrets == nil {
+		return nil, secrets.ErrNoKey
+	}
+
+	return w.secrets.DecryptAll(encrypted, projectID.String())
+}
+
 func (w *WorkflowWorker) registerHandlers() {
 	w.mux.HandleFunc(TypeWorkflowTrigger, func(ctx context.Context, t *asynq.Task) error {
 		var payload WorkflowJob
@@ -58,27 +91,177 @@ func (w *WorkflowWorker) registerHandlers() {
 			return err
 		}
 
+		deploymentID, err := uuid.Parse(payload.DeploymentID)
+		if err != nil {
+			return fmt.Errorf("invalid deployment id %q: %w", payload.DeploymentID, asynq.SkipRetry)
+		}
+
+		deployment, err := w.db.GetDeploymentByID(ctx, deploymentID)
+		if err != nil {
+			return err
+		}
+		if deployment.Status != "QUEUED" {
+			return nil
+		}
+
+		vars, err := w.buildEnv(ctx, deploymentID)
+		if err != nil {
+			w.failDeployment(deploymentID, "failed to load environment variables: "+err.Error())
+			return fmt.Errorf("failed to load environment variables: %v: %w", err, asynq.SkipRetry)
+		}
+
+		token, err := utils.GenerateToken()
+		if err == nil {
+			err = w.db.UpdateDeployment(ctx, deploymentID, db.Deployment{BuildTokenHash: security.HashToken(token)})
+		}
+		if err != nil {
+			return w.retryOrFail(ctx, deploymentID, err)
+		}
+
 		fmt.Println("triggering workflow")
 
-		return w.workflowClient.TriggerWorkflow(ctx, workflow.TriggerWorkflowConfig{
-			Owner:        payload.Owner,
-			Repo:         payload.Repo,
-			WorkflowFile: payload.Workflow,
-			Ref:          payload.Ref,
-			GithubToken:  payload.GithubToken,
-			Inputs: workflow.Input{
-				GitURL:       payload.GitURL,
-				ApiURL:       payload.ApiURL,
-				ApiKey:       payload.ApiKey,
-				BucketID:     payload.BucketID,
-				ProjectSlug:  payload.ProjectSlug,
-				DeploymentID: payload.DeploymentID,
-				UserEnv:      payload.UserEnv,
-			},
+		err = w.runner.Run(ctx, workflow.Input{
+			GitURL:        payload.GitURL,
+			ApiURL:        payload.ApiURL,
+			ApiKey:        payload.ApiKey,
+			BucketID:      payload.BucketID,
+			ProjectSlug:   payload.ProjectSlug,
+			DeploymentID:  payload.DeploymentID,
+			Framework:     payload.Framework,
+			BuildSettings: payload.BuildSettings,
+			Env:           vars,
+			Token:         token,
 		})
+		if err != nil {
+			return w.retryOrFail(ctx, deploymentID, err)
+		}
+
+		return nil
+	})
+
+	w.mux.HandleFunc(TypeBuildResult, func(ctx context.Context, t *asynq.Task) error {
+		var result BuildResult
+
+		if err := json.Unmarshal(t.Payload(), &result); err != nil {
+			return err
+		}
+
+		deploymentID, err := uuid.Parse(result.DeploymentID)
+		if err != nil {
+			return fmt.Errorf("invalid deployment id %q: %w", result.DeploymentID, asynq.SkipRetry)
+		}
+
+		if err := w.db.VerifyBuildToken(ctx, deploymentID, result.Token); err != nil {
+			return fmt.Errorf("rejected build result for %s: %w", deploymentID, asynq.SkipRetry)
+		}
+
+		return w.applyBuildResult(ctx, deploymentID, result)
 	})
 }
 
+func (w *WorkflowWorker) retryOrFail(ctx context.Context, deploymentID uuid.UUID, err error) error {
+	if !w.pending(deploymentID) {
+		return nil
+	}
+
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	if retried < maxRetry && ctx.Err() == nil {
+		return err
+	}
+
+	w.failDeployment(deploymentID, "build failed to run: "+err.Error())
+	return fmt.Errorf("%v: %w", err, asynq.SkipRetry)
+}
+
+func (w *WorkflowWorker) pending(deploymentID uuid.UUID) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	deployment, err := w.db.GetDeploymentByID(ctx, deploymentID)
+	return err != nil || deployment.Status == "QUEUED"
+}
+
+func (w *WorkflowWorker) failDeployment(deploymentID uuid.UUID, message string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	w.redis.StreamAdd(ctx, "deployment_logs:"+deploymentID.String(), map[string]interface{}{"message": message})
+
+	if err := w.db.UpdateDeployment(ctx, deploymentID, db.Deployment{Status: "FAILED"}); err != nil {
+		log.Printf("Failed to mark deployment %s as failed: %v", deploymentID, err)
+	}
+	if err := w.db.ClearBuildToken(ctx, deploymentID); err != nil {
+		log.Printf("Failed to clear build token for %s: %v", deploymentID, err)
+	}
+
+	w.finalizeLogs(ctx, deploymentID)
+}
+
+func (w *WorkflowWorker) applyBuildResult(ctx context.Context, deploymentID uuid.UUID, result BuildResult) error {
+	update := db.Deployment{
+		Routes:         []byte(result.Routes),
+		PackageManager: result.PackageManager,
+		Functions:      result.Functions,
+	}
+
+	if result.Status != "SUCCESS" {
+		update.Status = "FAILED"
+	}
+
+	if err := w.db.UpdateDeployment(ctx, deploymentID, update); err != nil {
+		return err
+	}
+
+	if result.Status == "SUCCESS" {
+		project, err := w.db.CompleteDeployment(ctx, deploymentID)
+		if err != nil {
+			return err
+		}
+
+		w.redis.Del(ctx, utils.GetSiteCacheKey(project.SubDomain))
+		w.redis.Del(ctx, fmt.Sprintf("project:slug:%s", project.SubDomain))
+	}
+
+	if err := w.db.ClearBuildToken(ctx, deploymentID); err != nil {
+		return err
+	}
+
+	w.finalizeLogs(ctx, deploymentID)
+
+	return nil
+}
+
+func (w *WorkflowWorker) finalizeLogs(ctx context.Context, deploymentID uuid.UUID) {
+	streamName := "deployment_logs:" + deploymentID.String()
+
+	messages, err := w.redis.XRange(ctx, streamName, "-", "+").Result()
+	if err != nil {
+		log.Println("Failed to read stream:", err)
+		return
+	}
+
+	var logs []db.LogEvent
+
+	for _, msg := range messages {
+		if m, ok := msg.Values["message"].(string); ok {
+			logs = append(logs, db.LogEvent{
+				DeploymentID: deploymentID,
+				Log:          m,
+			})
+		}
+	}
+
+	if len(logs) > 0 {
+		if err := w.db.CreateLogEvents(ctx, &logs); err != nil {
+			log.Println("Failed to save logs to DB:", err)
+			return
+		}
+	}
+
+	w.redis.Del(ctx, streamName)
+}
+
 func (w *WorkflowWorker) Start() {
 	fmt.Println("running workflow worker")
 	if err := w.server.Run(w.mux); err != nil {
@@ -0,0 +1,66 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestTakeToken(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	rd := NewRedisClient("redis://" + mr.Addr())
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mr.SetTime(now)
+
+	for i := 0; i < 3; i++ {
+		allowed, _, err := rd.TakeToken(ctx, "ratelimit:test", 2, 3)
+		assert.NilError(t, err)
+		assert.Assert(t, allowed, "request %d", i)
+	}
+
+	allowed, retryAfter, err := rd.TakeToken(ctx, "ratelimit:test", 2, 3)
+	assert.NilError(t, err)
+	assert.Assert(t, !allowed)
+	assert.Equal(t, retryAfter, 500*time.Millisecond)
+
+	mr.SetTime(now.Add(500 * time.Millisecond))
+	allowed, _, err = rd.TakeToken(ctx, "ratelimit:test", 2, 3)
+	assert.NilError(t, err)
+	assert.Assert(t, allowed)
+
+	allowed, _, err = rd.TakeToken(ctx, "ratelimit:other", 2, 3)
+	assert.NilError(t, err)
+	assert.Assert(t, allowed)
+
+	assert.Assert(t, mr.TTL("ratelimit:test") > 0)
+}
+
+func TestTakeTokenRefillsUpToBurst(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	rd := NewRedisClient("redis://" + mr.Addr())
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mr.SetTime(now)
+
+	allowed, _, err := rd.TakeToken(ctx, "ratelimit:test", 1, 2)
+	assert.NilError(t, err)
+	assert.Assert(t, allowed)
+
+	mr.SetTime(now.Add(time.Hour))
+
+	for i := 0; i < 2; i++ {
+		allowed, _, err = rd.TakeToken(ctx, "ratelimit:test", 1, 2)
+		assert.NilError(t, err)
+		assert.Assert(t, allowed)
+	}
+
+	allowed, _, err = rd.TakeToken(ctx, "ratelimit:test", 1, 2)
+	assert.NilError(t, err)
+	assert.Assert(t, !allowed)
+}
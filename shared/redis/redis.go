@@ -34,6 +34,26 @@ func (r *RedisClient) Del(ctx context.Context, key string) {
 	r.client.Del(ctx, key)
 }
 
+func (r *RedisClient) HSetWithTTL(ctx context.Context, key string, expiration time.Duration, values ...interface{}) error {
+	pipe := r.client.TxPipeline()
+	pipe.HSet(ctx, key, values...)
+	pipe.Expire(ctx, key, expiration)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisClient) HMGet(ctx context.Context, key string, fields ...string) ([]interface{}, error) {
+	return r.client.HMGet(ctx, key, fields...).Result()
+}
+
+func (r *RedisClient) Publish(ctx context.Context, channel string, message interface{}) error {
+	return r.client.Publish(ctx, channel, message).Err()
+}
+
+func (r *RedisClient) Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
+	return r.client.Subscribe(ctx, channels...)
+}
+
 func (r *RedisClient) RPush(ctx context.Context, key string, value interface{}) *redis.IntCmd {
 	return r.client.RPush(ctx, key, value)
 }
@@ -0,0 +1,168 @@
+package routing
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+type segmentKind int
+
+const (
+	literalSegment segmentKind = iota
+	paramSegment
+	globSegment
+	splatSegment
+)
+
+type segment struct {
+	kind  segmentKind
+	value string
+}
+
+type pattern []segment
+
+var (
+	paramName        = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	destinationParam = regexp.MustCompile(`:([A-Za-z_][A-Za-z0-9_]*)\*?`)
+)
+
+func splitSegments(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+func compilePattern(source string) (pattern, error) {
+	if !strings.HasPrefix(source, "/") {
+		return nil, fmt.Errorf("source %q must start with /", source)
+	}
+
+	var compiled pattern
+	seen := make(map[string]bool)
+	splats := 0
+
+	for _, part := range splitSegments(source) {
+		var seg segment
+
+		switch {
+		case part == "**":
+			seg = segment{kind: splatSegment}
+		case strings.HasPrefix(part, ":"):
+			name := strings.TrimPrefix(part, ":")
+			kind := paramSegment
+			if strings.HasSuffix(name, "*") {
+				name = strings.TrimSuffix(name, "*")
+				kind = splatSegment
+			}
+			if !paramName.MatchString(name) {
+				return nil, fmt.Errorf("source %q has invalid parameter %q", source, part)
+			}
+			if seen[name] {
+				return nil, fmt.Errorf("source %q repeats parameter %q", source, name)
+			}
+			seen[name] = true
+			seg = segment{kind: kind, value: name}
+		case strings.ContainsAny(part, "*?["):
+			if _, err := path.Match(part, ""); err != nil {
+				return nil, fmt.Errorf("source %q has invalid glob %q", source, part)
+			}
+			seg = segment{kind: globSegment, value: part}
+		default:
+			seg = segment{kind: literalSegment, value: part}
+		}
+
+		if seg.kind == splatSegment {
+			splats++
+			if splats > 1 {
+				return nil, fmt.Errorf("source %q may contain only one splat", source)
+			}
+		}
+
+		compiled = append(compiled, seg)
+	}
+
+	return compiled, nil
+}
+
+func (p pattern) params() map[string]bool {
+	names := make(map[string]bool)
+	for _, seg := range p {
+		if seg.value != "" && (seg.kind == paramSegment || seg.kind == splatSegment) {
+			names[seg.value] = true
+		}
+	}
+	return names
+}
+
+func (p pattern) match(requestPath string) (map[string]string, bool) {
+	params := make(map[string]string)
+	if matchSegments(p, splitSegments(requestPath), params) {
+		return params, true
+	}
+	return nil, false
+}
+
+func matchSegments(p pattern, parts []string, params map[string]string) bool {
+	if len(p) == 0 {
+		return len(parts) == 0
+	}
+
+	seg := p[0]
+
+	if seg.kind == splatSegment {
+		for i := len(parts); i >= 0; i-- {
+			if matchSegments(p[1:], parts[i:], params) {
+				if seg.value != "" {
+					params[seg.value] = strings.Join(parts[:i], "/")
+				}
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(parts) == 0 {
+		return false
+	}
+
+	switch seg.kind {
+	case literalSegment:
+		if seg.value != parts[0] {
+			return false
+		}
+	case globSegment:
+		if ok, _ := path.Match(seg.value, parts[0]); !ok {
+			return false
+		}
+	case paramSegment:
+		params[seg.value] = parts[0]
+	}
+
+	return matchSegments(p[1:], parts[1:], params)
+}
+
+func checkDestination(destination string, p pattern) error {
+	available := p.params()
+
+	for _, match := range destinationParam.FindAllStringSubmatch(destination, -1) {
+		if !available[match[1]] {
+			return fmt.Errorf("destination %q uses unknown parameter %q", destination, match[1])
+		}
+	}
+
+	return nil
+}
+
+func expandDestination(destination string, params map[string]string) string {
+	return destinationParam.ReplaceAllStringFunc(destination, func(token string) string {
+		name := strings.TrimSuffix(strings.TrimPrefix(token, ":"), "*")
+		if value, ok := params[name]; ok {
+			return value
+		}
+		return token
+	})
+}
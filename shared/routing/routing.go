@@ -0,0 +1,244 @@
+package routing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ConfigFile = "vercel.json"
+	maxRules   = 1024
+)
+
+type Redirect struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	StatusCode  int    `json:"statusCode,omitempty"`
+	Permanent   *bool  `json:"permanent,omitempty"`
+}
+
+type Rewrite struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+type Header struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type HeaderRule struct {
+	Source  string   `json:"source"`
+	Headers []Header `json:"headers"`
+}
+
+type Config struct {
+	Redirects []Redirect   `json:"redirects,omitempty"`
+	Rewrites  []Rewrite    `json:"rewrites,omitempty"`
+	Headers   []HeaderRule `json:"headers,omitempty"`
+}
+
+func (c *Config) Empty() bool {
+	return c == nil || len(c.Redirects)+len(c.Rewrites)+len(c.Headers) == 0
+}
+
+func Load(dir string) (*Config, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ConfigFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", ConfigFile, err)
+	}
+
+	if err := cfg.normalize(); err != nil {
+		return nil, fmt.Errorf("%s: %w", ConfigFile, err)
+	}
+
+	return &cfg, nil
+}
+
+func (c *Config) normalize() error {
+	if len(c.Redirects)+len(c.Rewrites)+len(c.Headers) > maxRules {
+		return fmt.Errorf("too many rules, at most %d are allowed", maxRules)
+	}
+
+	for i := range c.Redirects {
+		rule := &c.Redirects[i]
+
+		if rule.StatusCode == 0 {
+			rule.StatusCode = http.StatusPermanentRedirect
+			if rule.Permanent != nil && !*rule.Permanent {
+				rule.StatusCode = http.StatusTemporaryRedirect
+			}
+		}
+		rule.Permanent = nil
+
+		switch rule.StatusCode {
+		case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		default:
+			return fmt.Errorf("redirects[%d]: unsupported status code %d", i, rule.StatusCode)
+		}
+
+		if rule.Destination == "" {
+			return fmt.Errorf("redirects[%d]: destination is required", i)
+		}
+		if err := checkRule(rule.Source, rule.Destination); err != nil {
+			return fmt.Errorf("redirects[%d]: %w", i, err)
+		}
+	}
+
+	for i, rule := range c.Rewrites {
+		if !strings.HasPrefix(rule.Destination, "/") {
+			return fmt.Errorf("rewrites[%d]: destination %q must be a path starting with /", i, rule.Destination)
+		}
+		if err := checkRule(rule.Source, rule.Destination); err != nil {
+			return fmt.Errorf("rewrites[%d]: %w", i, err)
+		}
+	}
+
+	for i, rule := range c.Headers {
+		if _, err := compilePattern(rule.Source); err != nil {
+			return fmt.Errorf("headers[%d]: %w", i, err)
+		}
+		if len(rule.Headers) == 0 {
+			return fmt.Errorf("headers[%d]: at least one header is required", i)
+		}
+		for _, header := range rule.Headers {
+			if !validHeaderKey(header.Key) {
+				return fmt.Errorf("headers[%d]: invalid header name %q", i, header.Key)
+			}
+			if strings.ContainsAny(header.Value, "\r\n") {
+				return fmt.Errorf("headers[%d]: value of %q contains a line break", i, header.Key)
+			}
+		}
+	}
+
+	return nil
+}
+
+func checkRule(source, destination string) error {
+	p, err := compilePattern(source)
+	if err != nil {
+		return err
+	}
+	return checkDestination(destination, p)
+}
+
+func validHeaderKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, c := range key {
+		if c <= ' ' || c >= 0x7f || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, c) {
+			return false
+		}
+	}
+	return true
+}
+
+type compiledRedirect struct {
+	pattern     pattern
+	destination string
+	statusCode  int
+}
+
+type compiledRewrite struct {
+	pattern     pattern
+	destination string
+}
+
+type compiledHeaders struct {
+	pattern pattern
+	headers []Header
+}
+
+type Router struct {
+	redirects []compiledRedirect
+	rewrites  []compiledRewrite
+	headers   []compiledHeaders
+}
+
+func NewRouter(cfg *Config) (*Router, error) {
+	router := &Router{}
+	if cfg.Empty() {
+		return router, nil
+	}
+
+	if err := cfg.normalize(); err != nil {
+		return nil, err
+	}
+
+	for _, rule := range cfg.Redirects {
+		p, _ := compilePattern(rule.Source)
+		router.redirects = append(router.redirects, compiledRedirect{p, rule.Destination, rule.StatusCode})
+	}
+	for _, rule := range cfg.Rewrites {
+		p, _ := compilePattern(rule.Source)
+		router.rewrites = append(router.rewrites, compiledRewrite{p, rule.Destination})
+	}
+	for _, rule := range cfg.Headers {
+		p, _ := compilePattern(rule.Source)
+		router.headers = append(router.headers, compiledHeaders{p, rule.Headers})
+	}
+
+	return router, nil
+}
+
+func (r *Router) Redirect(path, query string) (string, int, bool) {
+	for _, rule := range r.redirects {
+		params, ok := rule.pattern.match(path)
+		if !ok {
+			continue
+		}
+
+		location := expandDestination(rule.destination, params)
+		if query != "" && !strings.Contains(location, "?") {
+			location += "?" + query
+		}
+
+		return location, rule.statusCode, true
+	}
+
+	return "", 0, false
+}
+
+func (r *Router) Rewrite(path string) (string, bool) {
+	for _, rule := range r.rewrites {
+		params, ok := rule.pattern.match(path)
+		if !ok {
+			continue
+		}
+
+		destination := expandDestination(rule.destination, params)
+		destination, _, _ = strings.Cut(destination, "?")
+
+		return destination, true
+	}
+
+	return path, false
+}
+
+func (r *Router) Headers(path string) []Header {
+	var headers []Header
+	for _, rule := range r.headers {
+		if _, ok := rule.pattern.match(path); ok {
+			headers = append(headers, rule.headers...)
+		}
+	}
+	return headers
+}
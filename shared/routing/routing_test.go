@@ -0,0 +1,119 @@
+package routing
+
+import (
+	"net/http"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestPatternMatch(t *testing.T) {
+	cases := []struct {
+		source string
+		path   string
+		ok     bool
+		params map[string]string
+	}{
+		{"/about", "/about", true, map[string]string{}},
+		{"/about", "/about/", true, map[string]string{}},
+		{"/about", "/contact", false, nil},
+		{"/blog/:slug", "/blog/hello", true, map[string]string{"slug": "hello"}},
+		{"/blog/:slug", "/blog/hello/world", false, nil},
+		{"/docs/:path*", "/docs/a/b/c", true, map[string]string{"path": "a/b/c"}},
+		{"/docs/:path*", "/docs", true, map[string]string{"path": ""}},
+		{"/assets/**", "/assets/js/app.js", true, map[string]string{}},
+		{"/**/index.html", "/a/b/index.html", true, map[string]string{}},
+		{"/*.js", "/app.js", true, map[string]string{}},
+		{"/*.js", "/app.css", false, nil},
+		{"/user/:id/posts/:post", "/user/7/posts/42", true, map[string]string{"id": "7", "post": "42"}},
+	}
+
+	for _, c := range cases {
+		p, err := compilePattern(c.source)
+		assert.NilError(t, err, c.source)
+
+		params, ok := p.match(c.path)
+		assert.Equal(t, ok, c.ok, c.source+" "+c.path)
+		assert.DeepEqual(t, params, c.params)
+	}
+}
+
+func TestCompilePatternErrors(t *testing.T) {
+	for _, source := range []string{"about", "/:1bad", "/:id/:id", "/[a-", "/**/**", "/:a*/x/:b*", "/**/**/**/**/x"} {
+		_, err := compilePattern(source)
+		assert.Assert(t, err != nil, source)
+	}
+}
+
+func TestParseRejectsInvalidRules(t *testing.T) {
+	cases := []string{
+		`{"redirects":[{"source":"/a","destination":"/b","statusCode":200}]}`,
+		`{"redirects":[{"source":"/a"}]}`,
+		`{"redirects":[{"source":"/a","destination":"/b/:slug"}]}`,
+		`{"rewrites":[{"source":"/a","destination":"https://example.com"}]}`,
+		`{"headers":[{"source":"/a","headers":[]}]}`,
+		`{"headers":[{"source":"/a","headers":[{"key":"Bad Key","value":"v"}]}]}`,
+		`{"headers":[{"source":"/a","headers":[{"key":"X-A","value":"a\r\nb"}]}]}`,
+	}
+
+	for _, data := range cases {
+		_, err := Parse([]byte(data))
+		assert.Assert(t, err != nil, data)
+	}
+}
+
+func TestRouter(t *testing.T) {
+	cfg, err := Parse([]byte(`{
+		"redirects": [
+			{"source": "/old/:slug", "destination": "/new/:slug"},
+			{"source": "/temp", "destination": "/elsewhere?from=temp", "permanent": false}
+		],
+		"rewrites": [
+			{"source": "/app/:path*", "destination": "/index.html?p=:path*"}
+		],
+		"headers": [
+			{"source": "/**", "headers": [{"key": "X-Frame-Options", "value": "DENY"}]},
+			{"source": "/assets/:file", "headers": [{"key": "Cache-Control", "value": "immutable"}]}
+		]
+	}`))
+	assert.NilError(t, err)
+
+	router, err := NewRouter(cfg)
+	assert.NilError(t, err)
+
+	location, status, ok := router.Redirect("/old/post", "ref=1")
+	assert.Assert(t, ok)
+	assert.Equal(t, location, "/new/post?ref=1")
+	assert.Equal(t, status, http.StatusPermanentRedirect)
+
+	location, status, ok = router.Redirect("/temp", "ref=1")
+	assert.Assert(t, ok)
+	assert.Equal(t, location, "/elsewhere?from=temp")
+	assert.Equal(t, status, http.StatusTemporaryRedirect)
+
+	_, _, ok = router.Redirect("/other", "")
+	assert.Assert(t, !ok)
+
+	destination, ok := router.Rewrite("/app/settings/profile")
+	assert.Assert(t, ok)
+	assert.Equal(t, destination, "/index.html")
+
+	destination, ok = router.Rewrite("/about")
+	assert.Assert(t, !ok)
+	assert.Equal(t, destination, "/about")
+
+	assert.DeepEqual(t, router.Headers("/assets/app.js"), []Header{
+		{Key: "X-Frame-Options", Value: "DENY"},
+		{Key: "Cache-Control", Value: "immutable"},
+	})
+	assert.DeepEqual(t, router.Headers("/"), []Header{{Key: "X-Frame-Options", Value: "DENY"}})
+}
+
+func TestEmptyRouter(t *testing.T) {
+	router, err := NewRouter(nil)
+	assert.NilError(t, err)
+
+	_, _, ok := router.Redirect("/", "")
+	assert.Assert(t, !ok)
+	assert.Equal(t, len(router.Headers("/")), 0)
+}
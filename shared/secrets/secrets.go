@@ -0,0 +1,101 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const version = "v1:"
+
+var (
+	ErrNoKey      = errors.New("encryption key is not configured")
+	ErrCiphertext = errors.New("invalid ciphertext")
+)
+
+type Box struct {
+	aead cipher.AEAD
+}
+
+func New(encodedKey string) (*Box, error) {
+	if encodedKey == "" {
+		return nil, ErrNoKey
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("encryption key must be base64: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Box{aead: aead}, nil
+}
+
+func (b *Box) Encrypt(plaintext, additional string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), []byte(additional))
+	return version + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (b *Box) Decrypt(ciphertext, additional string) (string, error) {
+	encoded, ok := strings.CutPrefix(ciphertext, version)
+	if !ok {
+		return "", ErrCiphertext
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < b.aead.NonceSize() {
+		return "", ErrCiphertext
+	}
+
+	nonce, sealed := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, sealed, []byte(additional))
+	if err != nil {
+		return "", ErrCiphertext
+	}
+
+	return string(plaintext), nil
+}
+
+func Mask(value string) string {
+	if len(value) < 12 {
+		return strings.Repeat("*", 8)
+	}
+	return strings.Repeat("*", 8) + value[len(value)-4:]
+}
+
+func AdditionalData(scope, name string) string {
+	return scope + "/" + name
+}
+
+func (b *Box) DecryptAll(values map[string]string, scope string) (map[string]string, error) {
+	plain := make(map[string]string, len(values))
+	for name, ciphertext := range values {
+		value, err := b.Decrypt(ciphertext, AdditionalData(scope, name))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		plain[name] = value
+	}
+	return plain, nil
+}
@@ -0,0 +1,102 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func newTestBox(t *testing.T) *Box {
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	box, err := New(base64.StdEncoding.EncodeToString(key))
+	assert.NilError(t, err)
+	return box
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("")
+	assert.ErrorIs(t, err, ErrNoKey)
+
+	_, err = New("not base64!")
+	assert.ErrorContains(t, err, "base64")
+
+	_, err = New(base64.StdEncoding.EncodeToString(make([]byte, 16)))
+	assert.ErrorContains(t, err, "32 bytes")
+}
+
+func TestRoundTrip(t *testing.T) {
+	box := newTestBox(t)
+	additional := AdditionalData("project", "API_KEY")
+
+	for _, value := range []string{"", "secret", strings.Repeat("x", 4096)} {
+		sealed, err := box.Encrypt(value, additional)
+		assert.NilError(t, err)
+		assert.Assert(t, strings.HasPrefix(sealed, version))
+
+		plain, err := box.Decrypt(sealed, additional)
+		assert.NilError(t, err)
+		assert.Equal(t, plain, value)
+	}
+
+	first, _ := box.Encrypt("secret", additional)
+	second, _ := box.Encrypt("secret", additional)
+	assert.Assert(t, first != second)
+}
+
+func TestDecryptRejectsTampering(t *testing.T) {
+	box := newTestBox(t)
+
+	sealed, err := box.Encrypt("secret", AdditionalData("project", "API_KEY"))
+	assert.NilError(t, err)
+
+	_, err = box.Decrypt(sealed, AdditionalData("project", "OTHER_KEY"))
+	assert.ErrorIs(t, err, ErrCiphertext)
+
+	_, err = box.Decrypt(sealed, AdditionalData("other-project", "API_KEY"))
+	assert.ErrorIs(t, err, ErrCiphertext)
+
+	_, err = newTestBox(t).Decrypt(sealed, AdditionalData("project", "API_KEY"))
+	assert.ErrorIs(t, err, ErrCiphertext)
+
+	raw, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, version))
+	raw[len(raw)-1] ^= 1
+	_, err = box.Decrypt(version+base64.StdEncoding.EncodeToString(raw), AdditionalData("project", "API_KEY"))
+	assert.ErrorIs(t, err, ErrCiphertext)
+
+	for _, invalid := range []string{"", "secret", "v1:", "v1:!!!", "v2:" + strings.TrimPrefix(sealed, version)} {
+		_, err := box.Decrypt(invalid, AdditionalData("project", "API_KEY"))
+		assert.ErrorIs(t, err, ErrCiphertext, invalid)
+	}
+}
+
+func TestDecryptAll(t *testing.T) {
+	box := newTestBox(t)
+
+	encrypted := map[string]string{}
+	for name, value := range map[string]string{"API_KEY": "one", "TOKEN": "two"} {
+		sealed, err := box.Encrypt(value, AdditionalData("project", name))
+		assert.NilError(t, err)
+		encrypted[name] = sealed
+	}
+
+	plain, err := box.DecryptAll(encrypted, "project")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, plain, map[string]string{"API_KEY": "one", "TOKEN": "two"})
+
+	encrypted["TOKEN"], encrypted["API_KEY"] = encrypted["API_KEY"], encrypted["TOKEN"]
+	_, err = box.DecryptAll(encrypted, "project")
+	assert.ErrorIs(t, err, ErrCiphertext)
+
+	_, err = box.DecryptAll(map[string]string{"API_KEY": encrypted["TOKEN"]}, "other")
+	assert.ErrorIs(t, err, ErrCiphertext)
+}
+
+func TestMask(t *testing.T) {
+	assert.Equal(t, Mask("short"), "********")
+	assert.Equal(t, Mask("sk_live_abcdef1234"), "********1234")
+}
@@ -0,0 +1,82 @@
+package security
+
+import (
+	"fmt"
+	"math"
+	"net/netip"
+	"strings"
+)
+
+const maxDenyListEntries = 1024
+
+type Firewall struct {
+	RequestsPerSecond float64  `json:"requests_per_second,omitempty"`
+	Burst             int      `json:"burst,omitempty"`
+	DenyList          []string `json:"deny_list,omitempty"`
+
+	denied []netip.Prefix
+}
+
+func parseDenyEntry(entry string) (netip.Prefix, error) {
+	entry = strings.TrimSpace(entry)
+
+	if strings.Contains(entry, "/") {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func (f *Firewall) Normalize() error {
+	if f.RequestsPerSecond < 0 || math.IsNaN(f.RequestsPerSecond) || math.IsInf(f.RequestsPerSecond, 0) {
+		return fmt.Errorf("requests_per_second must be a positive number")
+	}
+	if f.Burst < 0 {
+		return fmt.Errorf("burst must not be negative")
+	}
+	if f.RequestsPerSecond > 0 && f.Burst == 0 {
+		f.Burst = int(math.Ceil(f.RequestsPerSecond))
+	}
+
+	if len(f.DenyList) > maxDenyListEntries {
+		return fmt.Errorf("deny_list has more than %d entries", maxDenyListEntries)
+	}
+
+	denied := make([]netip.Prefix, 0, len(f.DenyList))
+	for i, entry := range f.DenyList {
+		prefix, err := parseDenyEntry(entry)
+		if err != nil {
+			return fmt.Errorf("deny_list entry %q is not an IP address or CIDR range", entry)
+		}
+		f.DenyList[i] = prefix.String()
+		denied = append(denied, prefix)
+	}
+	f.denied = denied
+
+	return nil
+}
+
+func (f Firewall) RateLimited() bool {
+	return f.RequestsPerSecond > 0 && f.Burst > 0
+}
+
+func (f Firewall) Denies(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	for _, prefix := range f.denied {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
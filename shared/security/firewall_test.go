@@ -0,0 +1,41 @@
+package security
+
+import (
+	"net/netip"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestFirewallNormalize(t *testing.T) {
+	f := Firewall{RequestsPerSecond: 2.5, DenyList: []string{" 10.0.0.7/8 ", "::ffff:192.0.2.1", "2001:db8::1"}}
+	assert.NilError(t, f.Normalize())
+
+	assert.Equal(t, f.Burst, 3)
+	assert.DeepEqual(t, f.DenyList, []string{"10.0.0.0/8", "192.0.2.1/32", "2001:db8::1/128"})
+	assert.Assert(t, f.RateLimited())
+
+	assert.ErrorContains(t, (&Firewall{RequestsPerSecond: -1}).Normalize(), "requests_per_second")
+	assert.ErrorContains(t, (&Firewall{Burst: -1}).Normalize(), "burst")
+	assert.ErrorContains(t, (&Firewall{DenyList: []string{"example.com"}}).Normalize(), "not an IP")
+	assert.ErrorContains(t, (&Firewall{DenyList: make([]string, maxDenyListEntries+1)}).Normalize(), "more than")
+
+	assert.Assert(t, !(Firewall{}).RateLimited())
+}
+
+func TestFirewallDenies(t *testing.T) {
+	f := Firewall{DenyList: []string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32"}}
+	assert.NilError(t, f.Normalize())
+
+	assert.Assert(t, f.Denies(netip.MustParseAddr("10.1.2.3")))
+	assert.Assert(t, f.Denies(netip.MustParseAddr("::ffff:10.1.2.3")))
+	assert.Assert(t, f.Denies(netip.MustParseAddr("192.0.2.1")))
+	assert.Assert(t, f.Denies(netip.MustParseAddr("2001:db8:1::5")))
+	assert.Assert(t, !f.Denies(netip.MustParseAddr("192.0.2.2")))
+	assert.Assert(t, !f.Denies(netip.MustParseAddr("11.0.0.1")))
+
+	f.DenyList = append(f.DenyList, "11.0.0.1")
+	assert.Assert(t, !f.Denies(netip.MustParseAddr("11.0.0.1")))
+	assert.NilError(t, f.Normalize())
+	assert.Assert(t, f.Denies(netip.MustParseAddr("11.0.0.1")))
+}
@@ -0,0 +1,123 @@
+package security
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chrollo-lucifer-12/shared/routing"
+)
+
+const (
+	PresetDefault = "default"
+	PresetStrict  = "strict"
+	PresetRelaxed = "relaxed"
+	PresetOff     = "off"
+)
+
+const (
+	ContentSecurityPolicy   = "Content-Security-Policy"
+	StrictTransportSecurity = "Strict-Transport-Security"
+	FrameOptions            = "X-Frame-Options"
+	ReferrerPolicy          = "Referrer-Policy"
+	PermissionsPolicy       = "Permissions-Policy"
+)
+
+var policyHeaders = []string{
+	ContentSecurityPolicy,
+	StrictTransportSecurity,
+	FrameOptions,
+	ReferrerPolicy,
+	PermissionsPolicy,
+}
+
+const defaultContentSecurityPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:;"
+
+var presets = map[string]map[string]string{
+	PresetDefault: {
+		ContentSecurityPolicy: defaultContentSecurityPolicy,
+	},
+	PresetStrict: {
+		ContentSecurityPolicy: defaultContentSecurityPolicy,
+		FrameOptions:          "DENY",
+		ReferrerPolicy:        "no-referrer",
+		PermissionsPolicy:     "camera=(), microphone=(), geolocation=(), payment=()",
+	},
+	PresetRelaxed: {
+		ContentSecurityPolicy: "upgrade-insecure-requests; frame-ancestors 'self'",
+		FrameOptions:          "SAMEORIGIN",
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+	},
+	PresetOff: {},
+}
+
+type HeaderPolicy struct {
+	Preset                  string  `json:"preset"`
+	ContentSecurityPolicy   *string `json:"content_security_policy,omitempty"`
+	StrictTransportSecurity *string `json:"strict_transport_security,omitempty"`
+	FrameOptions            *string `json:"frame_options,omitempty"`
+	ReferrerPolicy          *string `json:"referrer_policy,omitempty"`
+	PermissionsPolicy       *string `json:"permissions_policy,omitempty"`
+}
+
+func (p HeaderPolicy) overrides() map[string]*string {
+	return map[string]*string{
+		ContentSecurityPolicy:   p.ContentSecurityPolicy,
+		StrictTransportSecurity: p.StrictTransportSecurity,
+		FrameOptions:            p.FrameOptions,
+		ReferrerPolicy:          p.ReferrerPolicy,
+		PermissionsPolicy:       p.PermissionsPolicy,
+	}
+}
+
+func (p HeaderPolicy) Validate() error {
+	if _, ok := presets[p.preset()]; !ok {
+		return fmt.Errorf("unknown preset %q", p.Preset)
+	}
+
+	for name, value := range p.overrides() {
+		if value == nil {
+			continue
+		}
+		if strings.ContainsAny(*value, "\r\n") {
+			return fmt.Errorf("%s contains a line break", name)
+		}
+	}
+
+	if p.StrictTransportSecurity != nil && *p.StrictTransportSecurity != "" &&
+		!strings.HasPrefix(strings.ToLower(*p.StrictTransportSecurity), "max-age=") {
+		return fmt.Errorf("%s must start with max-age=", StrictTransportSecurity)
+	}
+
+	if p.FrameOptions != nil {
+		switch strings.ToUpper(*p.FrameOptions) {
+		case "", "DENY", "SAMEORIGIN":
+		default:
+			return fmt.Errorf("%s must be DENY or SAMEORIGIN", FrameOptions)
+		}
+	}
+
+	return nil
+}
+
+func (p HeaderPolicy) preset() string {
+	if p.Preset == "" {
+		return PresetDefault
+	}
+	return p.Preset
+}
+
+func (p HeaderPolicy) Headers() []routing.Header {
+	values := presets[p.preset()]
+	overrides := p.overrides()
+
+	headers := make([]routing.Header, 0, len(policyHeaders))
+	for _, name := range policyHeaders {
+		value := values[name]
+		if override := overrides[name]; override != nil {
+			value = *override
+		}
+		headers = append(headers, routing.Header{Key: name, Value: value})
+	}
+
+	return headers
+}
@@ -0,0 +1,61 @@
+package security
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func headerMap(p HeaderPolicy) map[string]string {
+	values := make(map[string]string)
+	for _, h := range p.Headers() {
+		if h.Value != "" {
+			values[h.Key] = h.Value
+		}
+	}
+	return values
+}
+
+func TestDefaultPolicyOnlySetsCSP(t *testing.T) {
+	assert.DeepEqual(t, headerMap(HeaderPolicy{}), map[string]string{
+		ContentSecurityPolicy: defaultContentSecurityPolicy,
+	})
+}
+
+func TestPresetsNeverSendHSTS(t *testing.T) {
+	for preset := range presets {
+		values := headerMap(HeaderPolicy{Preset: preset})
+		_, ok := values[StrictTransportSecurity]
+		assert.Assert(t, !ok, preset)
+	}
+}
+
+func TestHSTSIsOptIn(t *testing.T) {
+	hsts := "max-age=31536000; includeSubDomains"
+	policy := HeaderPolicy{Preset: PresetRelaxed, StrictTransportSecurity: &hsts}
+
+	assert.NilError(t, policy.Validate())
+	assert.Equal(t, headerMap(policy)[StrictTransportSecurity], hsts)
+}
+
+func TestOverridesClearPresetValues(t *testing.T) {
+	empty := ""
+	policy := HeaderPolicy{Preset: PresetStrict, ContentSecurityPolicy: &empty}
+
+	values := headerMap(policy)
+	_, ok := values[ContentSecurityPolicy]
+	assert.Assert(t, !ok)
+	assert.Equal(t, values[FrameOptions], "DENY")
+}
+
+func TestHeaderPolicyValidate(t *testing.T) {
+	bad := "max-age=1\r\nX-Injected: 1"
+	frame := "ALLOW-FROM https://example.com"
+	hsts := "includeSubDomains"
+
+	assert.ErrorContains(t, HeaderPolicy{Preset: "paranoid"}.Validate(), "unknown preset")
+	assert.ErrorContains(t, HeaderPolicy{ContentSecurityPolicy: &bad}.Validate(), "line break")
+	assert.ErrorContains(t, HeaderPolicy{FrameOptions: &frame}.Validate(), "DENY or SAMEORIGIN")
+	assert.ErrorContains(t, HeaderPolicy{StrictTransportSecurity: &hsts}.Validate(), "max-age")
+	assert.NilError(t, HeaderPolicy{Preset: PresetOff}.Validate())
+}
@@ -0,0 +1,67 @@
+package security
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	ProtectionInherit  = "inherit"
+	ProtectionNone     = "none"
+	ProtectionBasic    = "basic"
+	ProtectionPassword = "password"
+)
+
+const BypassTokenParam = "x-protection-bypass"
+
+type Protection struct {
+	Mode            string `json:"mode,omitempty"`
+	Username        string `json:"username,omitempty"`
+	PasswordHash    string `json:"password_hash,omitempty"`
+	BypassTokenHash string `json:"bypass_token_hash,omitempty"`
+}
+
+func (p Protection) Enabled() bool {
+	return p.Mode == ProtectionBasic || p.Mode == ProtectionPassword
+}
+
+func (p Protection) Validate() error {
+	switch p.Mode {
+	case "", ProtectionNone:
+		return nil
+	case ProtectionBasic:
+		if p.Username == "" {
+			return fmt.Errorf("basic protection requires a username")
+		}
+	case ProtectionPassword:
+	default:
+		return fmt.Errorf("unknown protection mode %q", p.Mode)
+	}
+
+	if p.PasswordHash == "" {
+		return fmt.Errorf("%s protection requires a password", p.Mode)
+	}
+
+	return nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (p Protection) CheckBypassToken(token string) bool {
+	if token == "" || p.BypassTokenHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(p.BypassTokenHash)) == 1
+}
+
+func ResolveProtection(project, deployment Protection) Protection {
+	if deployment.Mode != "" {
+		return deployment
+	}
+	return project
+}
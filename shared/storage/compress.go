@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+
+	"github.com/andybalholm/brotli"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const minCompressSize = 1024
+
+var Encodings = []string{"br", "gzip"}
+
+var EncodingSuffixes = map[string]string{
+	"br":   ".br",
+	"gzip": ".gz",
+}
+
+func compress(encoding string, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch encoding {
+	case "br":
+		w := brotli.NewWriterLevel(&buf, brotli.BestCompression)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case "gzip":
+		w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (s *S3Storage) uploadCompressedVariants(ctx context.Context, filePath, objectKey, contentType string, size int64, metadata map[string]string, logger func(string)) ([]string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var uploaded []string
+
+	for _, encoding := range Encodings {
+		suffix := EncodingSuffixes[encoding]
+
+		compressed, err := compress(encoding, data)
+		if err != nil {
+			return nil, err
+		}
+
+		if int64(len(compressed)) >= size {
+			continue
+		}
+
+		length := int64(len(compressed))
+
+		_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:          aws.String(s.bucket),
+			Key:             aws.String(objectKey + suffix),
+			Body:            bytes.NewReader(compressed),
+			ContentType:     aws.String(contentType),
+			ContentEncoding: aws.String(encoding),
+			ContentLength:   &length,
+			Metadata:        metadata,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		logger("Uploaded successfully: " + objectKey + suffix)
+		uploaded = append(uploaded, encoding)
+	}
+
+	return uploaded, nil
+}
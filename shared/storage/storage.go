@@ -2,20 +2,53 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 	"github.com/chrollo-lucifer-12/shared/utils"
 	"github.com/google/uuid"
 	"golang.org/x/sync/errgroup"
 )
 
+type Object struct {
+	Body            io.ReadCloser
+	ETag            string
+	LastModified    time.Time
+	ContentLength   int64
+	ContentType     string
+	ContentEncoding string
+	Fingerprinted   bool
+	Encodings       []string
+}
+
+const (
+	FingerprintedMetadata = "fingerprinted"
+	EncodingsMetadata     = "encodings"
+)
+
+func isFingerprinted(metadata map[string]string) bool {
+	return metadata[FingerprintedMetadata] == "true"
+}
+
+func objectEncodings(metadata map[string]string) []string {
+	if metadata[EncodingsMetadata] == "" {
+		return nil
+	}
+	return strings.Split(metadata[EncodingsMetadata], ",")
+}
+
 type S3Storage struct {
 	client *s3.Client
 	bucket string
@@ -45,7 +78,12 @@ func NewS3Storage(endpoint, accessKey, secretKey, region, bucket string) (*S3Sto
 	}, nil
 }
 
-func (s *S3Storage) UploadDirectory(ctx context.Context, localDir, slug string, deploymentIDUUID uuid.UUID, logger func(string)) error {
+type UploadOptions struct {
+	Compress      bool
+	Fingerprinted func(path string) bool
+}
+
+func (s *S3Storage) UploadDirectory(ctx context.Context, localDir, slug string, deploymentIDUUID uuid.UUID, opts UploadOptions, logger func(string)) error {
 
 	logger("Starting directory upload to S3...")
 	baseDir, err := filepath.Abs(localDir)
@@ -60,6 +98,9 @@ func (s *S3Storage) UploadDirectory(ctx context.Context, localDir, slug string,
 		if err != nil {
 			return err
 		}
+		if d.IsDir() && path != baseDir && (d.Name() == ".git" || d.Name() == "node_modules") {
+			return filepath.SkipDir
+		}
 		if !d.IsDir() {
 			files = append(files, path)
 		}
@@ -80,7 +121,7 @@ func (s *S3Storage) UploadDirectory(ctx context.Context, localDir, slug string,
 		filePath := filePath
 
 		g.Go(func() error {
-			err := s.uploadSingleFile(ctx, baseDir, filePath, slug, logger)
+			err := s.uploadSingleFile(ctx, baseDir, filePath, slug, opts, logger)
 			if err != nil {
 				logger("Upload failed: " + filePath + " -> " + err.Error())
 
@@ -95,6 +136,7 @@ func (s *S3Storage) UploadDirectory(ctx context.Context, localDir, slug string,
 func (s *S3Storage) uploadSingleFile(
 	ctx context.Context,
 	baseDir, filePath, slug string,
+	opts UploadOptions,
 	logger func(string),
 ) error {
 
@@ -121,12 +163,28 @@ func (s *S3Storage) uploadSingleFile(
 	contentType := utils.DetectContentType(objectKey)
 	size := stat.Size()
 
+	metadata := map[string]string{}
+	if opts.Fingerprinted != nil && opts.Fingerprinted(relPath) {
+		metadata[FingerprintedMetadata] = "true"
+	}
+
+	if opts.Compress && utils.IsCompressible(contentType) && size >= minCompressSize {
+		encodings, err := s.uploadCompressedVariants(ctx, filePath, objectKey, contentType, size, metadata, logger)
+		if err != nil {
+			return err
+		}
+		if len(encodings) > 0 {
+			metadata[EncodingsMetadata] = strings.Join(encodings, ",")
+		}
+	}
+
 	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:        aws.String(s.bucket),
 		Key:           aws.String(objectKey),
 		Body:          file,
 		ContentType:   aws.String(contentType),
 		ContentLength: &size,
+		Metadata:      metadata,
 	})
 
 	if err != nil {
@@ -137,7 +195,31 @@ func (s *S3Storage) uploadSingleFile(
 	return nil
 }
 
-func (s *S3Storage) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+func (s *S3Storage) UploadFile(ctx context.Context, filePath, objectKey, contentType string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	size := stat.Size()
+
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(objectKey),
+		Body:          file,
+		ContentType:   aws.String(contentType),
+		ContentLength: &size,
+	})
+	return err
+}
+
+func (s *S3Storage) GetObject(ctx context.Context, key string) (*Object, error) {
 
 	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
@@ -147,5 +229,76 @@ func (s *S3Storage) GetObject(ctx context.Context, key string) (io.ReadCloser, e
 		return nil, err
 	}
 
-	return out.Body, nil
+	return &Object{
+		Body:            out.Body,
+		ETag:            aws.ToString(out.ETag),
+		LastModified:    aws.ToTime(out.LastModified),
+		ContentLength:   aws.ToInt64(out.ContentLength),
+		ContentType:     aws.ToString(out.ContentType),
+		ContentEncoding: aws.ToString(out.ContentEncoding),
+		Fingerprinted:   isFingerprinted(out.Metadata),
+		Encodings:       objectEncodings(out.Metadata),
+	}, nil
+}
+
+func (s *S3Storage) GetObjectRange(ctx context.Context, key string, start, length int64) (*Object, error) {
+
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", start, start+length-1)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Object{
+		Body:            out.Body,
+		ETag:            aws.ToString(out.ETag),
+		LastModified:    aws.ToTime(out.LastModified),
+		ContentLength:   aws.ToInt64(out.ContentLength),
+		ContentType:     aws.ToString(out.ContentType),
+		ContentEncoding: aws.ToString(out.ContentEncoding),
+		Fingerprinted:   isFingerprinted(out.Metadata),
+		Encodings:       objectEncodings(out.Metadata),
+	}, nil
+}
+
+func (s *S3Storage) StatObject(ctx context.Context, key string) (*Object, error) {
+
+	out, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Object{
+		Body:            http.NoBody,
+		ETag:            aws.ToString(out.ETag),
+		LastModified:    aws.ToTime(out.LastModified),
+		ContentLength:   aws.ToInt64(out.ContentLength),
+		ContentType:     aws.ToString(out.ContentType),
+		ContentEncoding: aws.ToString(out.ContentEncoding),
+		Fingerprinted:   isFingerprinted(out.Metadata),
+		Encodings:       objectEncodings(out.Metadata),
+	}, nil
+}
+
+func IsNotFound(err error) bool {
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return true
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NoSuchKey", "NotFound":
+			return true
+		}
+	}
+
+	return false
 }
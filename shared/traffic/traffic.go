@@ -0,0 +1,68 @@
+package traffic
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CookieName  = "__deployment"
+	MaxCanaries = 5
+)
+
+type Split struct {
+	Weights map[uuid.UUID]int `json:"weights,omitempty"`
+}
+
+type Canary struct {
+	DeploymentID uuid.UUID
+	Weight       int
+}
+
+func (s Split) Enabled() bool {
+	return len(s.Canaries()) > 0
+}
+
+func (s Split) Total() int {
+	total := 0
+	for _, weight := range s.Weights {
+		total += weight
+	}
+	return total
+}
+
+func (s Split) Validate() error {
+	if len(s.Weights) > MaxCanaries {
+		return fmt.Errorf("at most %d canary deployments are allowed", MaxCanaries)
+	}
+
+	for id, weight := range s.Weights {
+		if weight < 0 || weight > 100 {
+			return fmt.Errorf("weight for %s must be between 0 and 100", id)
+		}
+	}
+
+	if total := s.Total(); total > 100 {
+		return fmt.Errorf("weights add up to %d, which is more than 100", total)
+	}
+
+	return nil
+}
+
+func (s Split) Canaries() []Canary {
+	canaries := make([]Canary, 0, len(s.Weights))
+	for id, weight := range s.Weights {
+		if weight > 0 {
+			canaries = append(canaries, Canary{DeploymentID: id, Weight: weight})
+		}
+	}
+
+	sort.Slice(canaries, func(i, j int) bool {
+		return bytes.Compare(canaries[i].DeploymentID[:], canaries[j].DeploymentID[:]) < 0
+	})
+
+	return canaries
+}
@@ -0,0 +1,40 @@
+package traffic
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"gotest.tools/v3/assert"
+)
+
+func TestSplitValidate(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+
+	assert.NilError(t, Split{}.Validate())
+	assert.NilError(t, Split{Weights: map[uuid.UUID]int{a: 60, b: 40}}.Validate())
+
+	assert.ErrorContains(t, Split{Weights: map[uuid.UUID]int{a: 60, b: 41}}.Validate(), "more than 100")
+	assert.ErrorContains(t, Split{Weights: map[uuid.UUID]int{a: -1}}.Validate(), "between 0 and 100")
+	assert.ErrorContains(t, Split{Weights: map[uuid.UUID]int{a: 101}}.Validate(), "between 0 and 100")
+
+	tooMany := Split{Weights: map[uuid.UUID]int{}}
+	for range MaxCanaries + 1 {
+		tooMany.Weights[uuid.New()] = 1
+	}
+	assert.ErrorContains(t, tooMany.Validate(), "at most")
+}
+
+func TestSplitCanaries(t *testing.T) {
+	a := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	b := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	c := uuid.MustParse("00000000-0000-0000-0000-000000000003")
+
+	split := Split{Weights: map[uuid.UUID]int{c: 5, a: 10, b: 0}}
+
+	assert.Assert(t, split.Enabled())
+	assert.Equal(t, split.Total(), 15)
+	assert.DeepEqual(t, split.Canaries(), []Canary{{DeploymentID: a, Weight: 10}, {DeploymentID: c, Weight: 5}})
+
+	assert.Assert(t, !Split{Weights: map[uuid.UUID]int{b: 0}}.Enabled())
+	assert.Assert(t, !Split{}.Enabled())
+}
@@ -4,12 +4,14 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -54,22 +56,19 @@ func GetPath(path []string) string {
 	return dir
 }
 
-func ParseUserEnv(jsonStr string) (map[string]string, error) {
-	var envVars map[string]string
-	err := json.Unmarshal([]byte(jsonStr), &envVars)
-	if err != nil {
-		return nil, err
+func WriteEnvFile(dir string, envVars map[string]string) error {
+	keys := make([]string, 0, len(envVars))
+	for k := range envVars {
+		keys = append(keys, k)
 	}
-	return envVars, nil
-}
+	sort.Strings(keys)
 
-func WriteEnvFile(dir string, envVars map[string]string) error {
-	path := filepath.Join(dir, ".env")
-	content := ""
-	for k, v := range envVars {
-		content += k + "=" + v + "\n"
+	var content strings.Builder
+	for _, k := range keys {
+		v := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`).Replace(envVars[k])
+		content.WriteString(k + `="` + v + "\"\n")
 	}
-	return os.WriteFile(path, []byte(content), 0644)
+	return os.WriteFile(filepath.Join(dir, ".env"), []byte(content.String()), 0600)
 }
 
 func GetCacheKey(subdomain, path string) string {
@@ -78,6 +77,58 @@ func GetCacheKey(subdomain, path string) string {
 	return hex.EncodeToString(hash[:])
 }
 
+const CacheInvalidationChannel = "cache:invalidate"
+
+func GetDeploymentPrefix(subdomain string, sequence int) string {
+	return subdomain + strconv.Itoa(sequence)
+}
+
+func GetPreviewSubdomain(subdomain string, sequence int) string {
+	return subdomain + "-" + strconv.Itoa(sequence)
+}
+
+func ParsePreviewSubdomain(label string) (string, int, bool) {
+	i := strings.LastIndex(label, "-")
+	if i <= 0 {
+		return "", 0, false
+	}
+
+	sequence, err := strconv.Atoi(label[i+1:])
+	if err != nil || sequence <= 0 {
+		return "", 0, false
+	}
+
+	return label[:i], sequence, true
+}
+
+func GetDomainCacheKey(host string) string {
+	return "domain:" + host
+}
+
+func GetSiteCacheKey(subdomain string) string {
+	return "site:" + subdomain
+}
+
+func GetPreviewSiteCachePattern(subdomain string) string {
+	return "site:" + subdomain + "-[0-9]*"
+}
+
+func GetObjectCacheKey(objectKey string) string {
+	return "object:" + objectKey
+}
+
+func GetDeploymentCachePattern(prefix string) string {
+	return "object:" + prefix + "/*"
+}
+
+func GetRateLimitKey(projectID, ip string) string {
+	return "ratelimit:" + projectID + ":" + ip
+}
+
+func GetBlockedCounterKey(projectID string, day time.Time) string {
+	return "blocked:" + projectID + ":" + day.UTC().Format("2006-01-02")
+}
+
 func HashPassword(password string) (string, error) {
 	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -117,3 +168,19 @@ func DetectContentType(path string) string {
 		return "application/octet-stream"
 	}
 }
+
+func IsCompressible(contentType string) bool {
+	contentType, _, _ = strings.Cut(contentType, ";")
+
+	switch {
+	case strings.HasPrefix(contentType, "text/"):
+		return true
+	}
+
+	switch contentType {
+	case "application/javascript", "application/json", "application/xml", "image/svg+xml", "application/wasm":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,205 @@
+package workflow
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const dockerAPIVersion = "/v1.41"
+
+type DockerConfig struct {
+	Socket  string
+	Image   string
+	Network string
+}
+
+type DockerRunner struct {
+	cfg    DockerConfig
+	client *http.Client
+}
+
+func NewDockerRunner(cfg DockerConfig) *DockerRunner {
+	if cfg.Socket == "" {
+		cfg.Socket = "/var/run/docker.sock"
+	}
+	if cfg.Image == "" {
+		cfg.Image = "sahil1107/build-server:latest"
+	}
+
+	return &DockerRunner{
+		cfg: cfg,
+		client: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", cfg.Socket)
+				},
+			},
+		},
+	}
+}
+
+func (r *DockerRunner) do(ctx context.Context, method, path string, body any, out any) (int, error) {
+	if body == nil {
+		return r.send(ctx, method, path, "", nil, out)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return 0, err
+	}
+	return r.send(ctx, method, path, "application/json", bytes.NewReader(data), out)
+}
+
+func (r *DockerRunner) send(ctx context.Context, method, path, contentType string, body io.Reader, out any) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, "http://docker"+dockerAPIVersion+path, body)
+	if err != nil {
+		return 0, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return resp.StatusCode, fmt.Errorf("docker %s %s: %s", method, path, strings.TrimSpace(string(message)))
+	}
+
+	switch out := out.(type) {
+	case nil:
+		_, err = io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, err
+	case jsonStream:
+		return resp.StatusCode, out(json.NewDecoder(resp.Body))
+	default:
+		return resp.StatusCode, json.NewDecoder(resp.Body).Decode(out)
+	}
+}
+
+type jsonStream func(*json.Decoder) error
+
+func (r *DockerRunner) pull(ctx context.Context) error {
+	image, tag := r.cfg.Image, "latest"
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image, tag = image[:i], image[i+1:]
+	}
+
+	query := url.Values{"fromImage": {image}, "tag": {tag}}
+	_, err := r.do(ctx, http.MethodPost, "/images/create?"+query.Encode(), nil, jsonStream(func(dec *json.Decoder) error {
+		for {
+			var progress struct {
+				Error string `json:"error"`
+			}
+			if err := dec.Decode(&progress); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if progress.Error != "" {
+				return fmt.Errorf("docker pull %s: %s", r.cfg.Image, progress.Error)
+			}
+		}
+	}))
+	return err
+}
+
+func (r *DockerRunner) create(ctx context.Context, in Input) (string, error) {
+	spec := map[string]any{
+		"Image": r.cfg.Image,
+		"Env":   buildEnv(in),
+		"HostConfig": map[string]any{
+			"NetworkMode": r.cfg.Network,
+		},
+	}
+
+	var created struct {
+		ID string `json:"Id"`
+	}
+
+	name := "build-" + in.DeploymentID
+	if status, err := r.do(ctx, http.MethodDelete, "/containers/"+name+"?force=true", nil, nil); err != nil && status != http.StatusNotFound {
+		return "", err
+	}
+
+	path := "/containers/create?" + url.Values{"name": {name}}.Encode()
+
+	status, err := r.do(ctx, http.MethodPost, path, spec, &created)
+	if status == http.StatusNotFound {
+		if err := r.pull(ctx); err != nil {
+			return "", err
+		}
+		_, err = r.do(ctx, http.MethodPost, path, spec, &created)
+	}
+	return created.ID, err
+}
+
+func (r *DockerRunner) copySecrets(ctx context.Context, id string, in Input) error {
+	data, err := json.Marshal(buildSecrets(in))
+	if err != nil {
+		return err
+	}
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	if err := tw.WriteHeader(&tar.Header{Name: BuildSecretsName, Mode: 0o400, Size: int64(len(data)), ModTime: time.Now()}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	query := url.Values{"path": {BuildSecretsDir}}
+	_, err = r.send(ctx, http.MethodPut, "/containers/"+id+"/archive?"+query.Encode(), "application/x-tar", &archive, nil)
+	return err
+}
+
+func (r *DockerRunner) Run(ctx context.Context, in Input) error {
+	id, err := r.create(ctx, in)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		cleanup, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		r.do(cleanup, http.MethodDelete, "/containers/"+id+"?force=true", nil, nil)
+	}()
+
+	if err := r.copySecrets(ctx, id, in); err != nil {
+		return err
+	}
+
+	if _, err := r.do(ctx, http.MethodPost, "/containers/"+id+"/start", nil, nil); err != nil {
+		return err
+	}
+
+	var result struct {
+		StatusCode int `json:"StatusCode"`
+	}
+	if _, err := r.do(ctx, http.MethodPost, "/containers/"+id+"/wait", nil, &result); err != nil {
+		return err
+	}
+
+	if result.StatusCode != 0 {
+		return fmt.Errorf("build container exited with status %d", result.StatusCode)
+	}
+	return nil
+}
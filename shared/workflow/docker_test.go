@@ -0,0 +1,174 @@
+package workflow
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+type fakeDocker struct {
+	mu        sync.Mutex
+	calls     []string
+	env       []string
+	secrets   BuildSecrets
+	mode      int64
+	missing   bool
+	pullError string
+	exitCode  int
+}
+
+func (d *fakeDocker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, dockerAPIVersion)
+	d.calls = append(d.calls, r.Method+" "+path)
+
+	switch {
+	case r.Method == http.MethodDelete && path == "/containers/build-abc":
+		http.Error(w, "no such container", http.StatusNotFound)
+	case path == "/images/create":
+		json.NewEncoder(w).Encode(map[string]string{"status": "Pulling from build-server"})
+		if d.pullError != "" {
+			json.NewEncoder(w).Encode(map[string]string{"error": d.pullError})
+			return
+		}
+		d.missing = false
+	case path == "/containers/create":
+		if d.missing {
+			http.Error(w, "no such image", http.StatusNotFound)
+			return
+		}
+		var spec struct {
+			Env []string `json:"Env"`
+		}
+		json.NewDecoder(r.Body).Decode(&spec)
+		d.env = spec.Env
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"Id": "container-1"})
+	case r.Method == http.MethodPut && path == "/containers/container-1/archive":
+		if r.URL.Query().Get("path") != BuildSecretsDir {
+			http.Error(w, "wrong path", http.StatusBadRequest)
+			return
+		}
+		tr := tar.NewReader(r.Body)
+		header, err := tr.Next()
+		if err != nil || header.Name != BuildSecretsName {
+			http.Error(w, "bad archive", http.StatusBadRequest)
+			return
+		}
+		d.mode = header.Mode
+		json.NewDecoder(tr).Decode(&d.secrets)
+	case path == "/containers/container-1/wait":
+		json.NewEncoder(w).Encode(map[string]int{"StatusCode": d.exitCode})
+	}
+}
+
+func newFakeDocker(t *testing.T, docker *fakeDocker) *DockerRunner {
+	t.Helper()
+
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.NilError(t, err)
+
+	server := httptest.NewUnstartedServer(docker)
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+
+	return NewDockerRunner(DockerConfig{Socket: socket, Image: "build-server:test"})
+}
+
+func TestDockerRunner(t *testing.T) {
+	t.Setenv("DSN", "postgres://secret")
+	t.Setenv("ENV_ENCRYPTION_KEY", "secret-key")
+	t.Setenv("REDIS_URL", "redis://secret")
+	t.Setenv("SUPABASE_ACCESS_KEY", "supabase-access")
+	t.Setenv("REGION", "us-east-1")
+
+	docker := &fakeDocker{}
+	runner := newFakeDocker(t, docker)
+
+	err := runner.Run(context.Background(), Input{DeploymentID: "abc", Token: "token", Env: map[string]string{"API_URL": "https://example.com"}})
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, docker.calls, []string{
+		"DELETE /containers/build-abc",
+		"POST /containers/create",
+		"PUT /containers/container-1/archive",
+		"POST /containers/container-1/start",
+		"POST /containers/container-1/wait",
+		"DELETE /containers/container-1",
+	})
+
+	assert.Assert(t, contains(docker.env, "DEPLOYMENT_ID=abc"))
+	assert.Assert(t, contains(docker.env, "REGION=us-east-1"))
+	for _, v := range docker.env {
+		for _, key := range []string{"DSN", "ENV_ENCRYPTION_KEY", "REDIS_URL", "SUPABASE_ACCESS_KEY", "BUILD_TOKEN", "BUILD_ENV"} {
+			assert.Assert(t, !strings.HasPrefix(v, key+"="), v)
+		}
+	}
+
+	assert.Equal(t, docker.mode, int64(0o400))
+	assert.DeepEqual(t, docker.secrets, BuildSecrets{
+		RedisURL:          "redis://secret",
+		SupabaseAccessKey: "supabase-access",
+		Token:             "token",
+		Env:               map[string]string{"API_URL": "https://example.com"},
+	})
+}
+
+func TestDockerRunnerPullsMissingImage(t *testing.T) {
+	docker := &fakeDocker{missing: true}
+	runner := newFakeDocker(t, docker)
+
+	err := runner.Run(context.Background(), Input{DeploymentID: "abc"})
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, docker.calls[:4], []string{
+		"DELETE /containers/build-abc",
+		"POST /containers/create",
+		"POST /images/create",
+		"POST /containers/create",
+	})
+}
+
+func TestDockerRunnerPullError(t *testing.T) {
+	docker := &fakeDocker{missing: true, pullError: "manifest unknown"}
+	runner := newFakeDocker(t, docker)
+
+	err := runner.Run(context.Background(), Input{DeploymentID: "abc"})
+	assert.ErrorContains(t, err, "manifest unknown")
+	assert.DeepEqual(t, docker.calls, []string{
+		"DELETE /containers/build-abc",
+		"POST /containers/create",
+		"POST /images/create",
+	})
+}
+
+func TestDockerRunnerExitStatus(t *testing.T) {
+	docker := &fakeDocker{exitCode: 1}
+	runner := newFakeDocker(t, docker)
+
+	err := runner.Run(context.Background(), Input{DeploymentID: "abc"})
+	assert.ErrorContains(t, err, "exited with status 1")
+	assert.Equal(t, docker.calls[len(docker.calls)-1], "DELETE /containers/container-1")
+}
+
+func contains(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+type LocalConfig struct {
+	Binary     string
+	WorkDir    string
+	SourceRoot string
+}
+
+var localEnvKeys = []string{"PATH", "HOME", "TMPDIR"}
+
+type LocalRunner struct {
+	cfg LocalConfig
+}
+
+func NewLocalRunner(cfg LocalConfig) *LocalRunner {
+	if cfg.Binary == "" {
+		cfg.Binary = "build-server"
+	}
+	if cfg.WorkDir == "" {
+		cfg.WorkDir = os.TempDir()
+	}
+	return &LocalRunner{cfg: cfg}
+}
+
+type prefixWriter struct {
+	prefix string
+}
+
+func (w prefixWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		log.Print(w.prefix + line)
+	}
+	return len(p), nil
+}
+
+func runLogged(ctx context.Context, out io.Writer, dir string, env []string, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd.Run()
+}
+
+func (r *LocalRunner) localSource(gitURL string) (string, bool) {
+	if r.cfg.SourceRoot == "" {
+		return "", false
+	}
+
+	path := strings.TrimPrefix(gitURL, "file://")
+	if !filepath.IsAbs(path) {
+		return "", false
+	}
+
+	root, err := filepath.EvalSymlinks(r.cfg.SourceRoot)
+	if err != nil {
+		return "", false
+	}
+	path, err = filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	info, err := os.Stat(path)
+	return path, err == nil && info.IsDir()
+}
+
+func writeSecretsFile(workDir string, secrets BuildSecrets) (string, error) {
+	data, err := json.Marshal(secrets)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp(workDir, "secrets-")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, BuildSecretsName)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return path, nil
+}
+
+func copySource(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case d.IsDir() && d.Name() == "node_modules":
+			return filepath.SkipDir
+		case d.IsDir():
+			return os.MkdirAll(target, 0o755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case !d.Type().IsRegular():
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
+
+func (r *LocalRunner) Run(ctx context.Context, in Input) error {
+	if strings.HasPrefix(in.GitURL, "-") {
+		return fmt.Errorf("invalid git URL %q", in.GitURL)
+	}
+
+	if err := os.MkdirAll(r.cfg.WorkDir, 0o755); err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp(r.cfg.WorkDir, "build-"+in.DeploymentID+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	secretsFile, err := writeSecretsFile(r.cfg.WorkDir, buildSecrets(in))
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(filepath.Dir(secretsFile))
+
+	out := prefixWriter{prefix: "[build " + in.DeploymentID + "] "}
+	env := append(buildEnv(in), forwardEnv(localEnvKeys)...)
+	env = append(env, "WORK_DIR="+dir, "BUILD_SECRETS_FILE="+secretsFile)
+
+	if source, ok := r.localSource(in.GitURL); ok {
+		if err := copySource(source, dir); err != nil {
+			return fmt.Errorf("copying %s failed: %w", source, err)
+		}
+	} else if err := runLogged(ctx, out, dir, env, "git", "clone", "--depth", "1", "--single-branch", "--", in.GitURL, "."); err != nil {
+		return fmt.Errorf("git clone failed: %w", err)
+	}
+
+	if err := runLogged(ctx, out, dir, env, r.cfg.Binary); err != nil {
+		return fmt.Errorf("build-server failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func fakeBuildServer(t *testing.T) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	record := filepath.Join(dir, "record")
+	script := filepath.Join(dir, "build-server")
+	body := "#!/bin/sh\nenv > " + record + ".env\nls -A > " + record + ".files\ncat \"$BUILD_SECRETS_FILE\" > " + record + ".secrets\n"
+	assert.NilError(t, os.WriteFile(script, []byte(body), 0o755))
+	return script, record
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	assert.NilError(t, err)
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestLocalRunnerSource(t *testing.T) {
+	t.Setenv("DSN", "postgres://secret")
+	t.Setenv("ENV_ENCRYPTION_KEY", "secret-key")
+	t.Setenv("REDIS_URL", "redis://secret")
+	t.Setenv("SUPABASE_SECRET_KEY", "supabase-secret")
+	t.Setenv("REGION", "us-east-1")
+
+	root := t.TempDir()
+	source := filepath.Join(root, "app")
+	assert.NilError(t, os.MkdirAll(filepath.Join(source, "node_modules", "dep"), 0o755))
+	assert.NilError(t, os.WriteFile(filepath.Join(source, "package.json"), []byte("{}"), 0o644))
+	assert.NilError(t, os.WriteFile(filepath.Join(source, "node_modules", "dep", "index.js"), nil, 0o644))
+	assert.NilError(t, os.Symlink("package.json", filepath.Join(source, "link.json")))
+
+	binary, record := fakeBuildServer(t)
+	runner := NewLocalRunner(LocalConfig{Binary: binary, WorkDir: t.TempDir(), SourceRoot: root})
+
+	err := runner.Run(context.Background(), Input{
+		GitURL:       "file://" + source,
+		DeploymentID: "abc",
+		Env:          map[string]string{"API_URL": "https://example.com"},
+		Token:        "token",
+	})
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, readLines(t, record+".files"), []string{"link.json", "package.json"})
+
+	env := map[string]string{}
+	for _, line := range readLines(t, record+".env") {
+		key, value, _ := strings.Cut(line, "=")
+		env[key] = value
+	}
+	assert.Equal(t, env["DEPLOYMENT_ID"], "abc")
+	assert.Equal(t, env["REGION"], "us-east-1")
+	assert.Assert(t, strings.HasPrefix(env["WORK_DIR"], runner.cfg.WorkDir))
+
+	for _, key := range []string{"DSN", "ENV_ENCRYPTION_KEY", "REDIS_URL", "SUPABASE_SECRET_KEY", "BUILD_ENV", "BUILD_TOKEN"} {
+		_, ok := env[key]
+		assert.Assert(t, !ok, key)
+	}
+
+	data, err := os.ReadFile(record + ".secrets")
+	assert.NilError(t, err)
+
+	var secrets BuildSecrets
+	assert.NilError(t, json.Unmarshal(data, &secrets))
+	assert.DeepEqual(t, secrets, BuildSecrets{
+		RedisURL:          "redis://secret",
+		SupabaseSecretKey: "supabase-secret",
+		Token:             "token",
+		Env:               map[string]string{"API_URL": "https://example.com"},
+	})
+
+	_, err = os.Stat(env["BUILD_SECRETS_FILE"])
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestLocalSource(t *testing.T) {
+	root := t.TempDir()
+	inside := filepath.Join(root, "app")
+	assert.NilError(t, os.Mkdir(inside, 0o755))
+	outside := t.TempDir()
+
+	runner := NewLocalRunner(LocalConfig{SourceRoot: root})
+
+	_, ok := runner.localSource(inside)
+	assert.Assert(t, ok)
+	_, ok = runner.localSource("file://" + inside)
+	assert.Assert(t, ok)
+	_, ok = runner.localSource(outside)
+	assert.Assert(t, !ok)
+	_, ok = runner.localSource(filepath.Join(inside, "..", "..", filepath.Base(outside)))
+	assert.Assert(t, !ok)
+	_, ok = runner.localSource("https://github.com/owner/repo.git")
+	assert.Assert(t, !ok)
+
+	_, ok = NewLocalRunner(LocalConfig{}).localSource(inside)
+	assert.Assert(t, !ok)
+}
+
+func TestLocalRunnerClonesOutsideSourceRoot(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		output, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput()
+		assert.NilError(t, err, string(output))
+	}
+	assert.NilError(t, os.WriteFile(filepath.Join(repo, "untracked.txt"), nil, 0o644))
+
+	binary, record := fakeBuildServer(t)
+	runner := NewLocalRunner(LocalConfig{Binary: binary, WorkDir: t.TempDir(), SourceRoot: t.TempDir()})
+
+	err := runner.Run(context.Background(), Input{GitURL: "file://" + repo, DeploymentID: "abc"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, readLines(t, record+".files"), []string{".git"})
+}
+
+func TestLocalRunnerBuildFailure(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "app")
+	assert.NilError(t, os.Mkdir(source, 0o755))
+
+	runner := NewLocalRunner(LocalConfig{Binary: "false", WorkDir: t.TempDir(), SourceRoot: root})
+
+	err := runner.Run(context.Background(), Input{GitURL: source, DeploymentID: "abc"})
+	assert.ErrorContains(t, err, "build-server failed")
+}
+
+func TestLocalRunnerRejectsOptionURLs(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "marker")
+	binary, record := fakeBuildServer(t)
+	runner := NewLocalRunner(LocalConfig{Binary: binary, WorkDir: t.TempDir()})
+
+	err := runner.Run(context.Background(), Input{GitURL: "--upload-pack=touch " + marker, DeploymentID: "abc"})
+	assert.ErrorContains(t, err, "invalid git URL")
+
+	_, err = os.Stat(marker)
+	assert.Assert(t, os.IsNotExist(err))
+	_, err = os.Stat(record + ".env")
+	assert.Assert(t, os.IsNotExist(err))
+}
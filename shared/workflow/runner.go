@@ -0,0 +1,65 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+const (
+	RunnerGitHub = "github"
+	RunnerLocal  = "local"
+	RunnerDocker = "docker"
+)
+
+type BuildRunner interface {
+	Run(ctx context.Context, in Input) error
+}
+
+type RunnerConfig struct {
+	Kind   string
+	GitHub TriggerWorkflowConfig
+	Local  LocalConfig
+	Docker DockerConfig
+}
+
+var buildEnvKeys = []string{
+	"REGION",
+	"SUPABASE_ENDPOINT",
+	"COMPRESS_ASSETS",
+}
+
+func buildEnv(in Input) []string {
+	vars := []string{
+		"DEPLOYMENT_ID=" + in.DeploymentID,
+		"SLUG=" + in.ProjectSlug,
+		"GIT_REPOSITORY_URL=" + in.GitURL,
+		"BUCKET_ID=" + in.BucketID,
+		"FRAMEWORK=" + in.Framework,
+		"BUILD_SETTINGS=" + in.BuildSettings,
+	}
+	return append(vars, forwardEnv(buildEnvKeys)...)
+}
+
+func forwardEnv(keys []string) []string {
+	var vars []string
+	for _, key := range keys {
+		if value, ok := os.LookupEnv(key); ok {
+			vars = append(vars, key+"="+value)
+		}
+	}
+	return vars
+}
+
+func NewBuildRunner(ctx context.Context, cfg RunnerConfig) (BuildRunner, error) {
+	switch cfg.Kind {
+	case "", RunnerGitHub:
+		return NewGitHubRunner(ctx, cfg.GitHub), nil
+	case RunnerLocal:
+		return NewLocalRunner(cfg.Local), nil
+	case RunnerDocker:
+		return NewDockerRunner(cfg.Docker), nil
+	default:
+		return nil, fmt.Errorf("unknown build runner %q", cfg.Kind)
+	}
+}
@@ -0,0 +1,85 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/chrollo-lucifer-12/shared/secrets"
+)
+
+const (
+	BuildSecretsDir  = "/run/build"
+	BuildSecretsName = "secrets.json"
+	BuildSecretsPath = BuildSecretsDir + "/" + BuildSecretsName
+)
+
+var ErrNoSealKey = errors.New("BUILD_SECRETS_KEY is required to send build secrets")
+
+type BuildSecrets struct {
+	RedisURL          string            `json:"redis_url"`
+	SupabaseAccessKey string            `json:"supabase_access_key"`
+	SupabaseSecretKey string            `json:"supabase_secret_key"`
+	Token             string            `json:"token,omitempty"`
+	Env               map[string]string `json:"env,omitempty"`
+	Sealed            string            `json:"sealed,omitempty"`
+	SealKey           string            `json:"seal_key,omitempty"`
+}
+
+type sealedInputs struct {
+	Token string            `json:"token"`
+	Env   map[string]string `json:"env,omitempty"`
+}
+
+func buildSecrets(in Input) BuildSecrets {
+	return BuildSecrets{
+		RedisURL:          os.Getenv("REDIS_URL"),
+		SupabaseAccessKey: os.Getenv("SUPABASE_ACCESS_KEY"),
+		SupabaseSecretKey: os.Getenv("SUPABASE_SECRET_KEY"),
+		Token:             in.Token,
+		Env:               in.Env,
+	}
+}
+
+func SealInputs(key string, in Input) (string, error) {
+	if key == "" {
+		return "", ErrNoSealKey
+	}
+
+	box, err := secrets.New(key)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(sealedInputs{Token: in.Token, Env: in.Env})
+	if err != nil {
+		return "", err
+	}
+
+	return box.Encrypt(string(data), in.DeploymentID)
+}
+
+func (s *BuildSecrets) Unseal(deploymentID string) error {
+	if s.Sealed == "" {
+		return nil
+	}
+
+	box, err := secrets.New(s.SealKey)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := box.Decrypt(s.Sealed, deploymentID)
+	if err != nil {
+		return err
+	}
+
+	var inputs sealedInputs
+	if err := json.Unmarshal([]byte(plaintext), &inputs); err != nil {
+		return err
+	}
+
+	s.Token, s.Env = inputs.Token, inputs.Env
+	s.Sealed, s.SealKey = "", ""
+	return nil
+}
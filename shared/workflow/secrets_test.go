@@ -0,0 +1,29 @@
+package workflow
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSealInputs(t *testing.T) {
+	in := Input{DeploymentID: "abc", Token: "token", Env: map[string]string{"API_URL": "https://example.com"}}
+
+	_, err := SealInputs("", in)
+	assert.ErrorIs(t, err, ErrNoSealKey)
+
+	key := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	sealed, err := SealInputs(key, in)
+	assert.NilError(t, err)
+	assert.Assert(t, !strings.Contains(sealed, "token"))
+	assert.Assert(t, !strings.Contains(sealed, "example.com"))
+
+	secrets := BuildSecrets{Sealed: sealed, SealKey: key}
+	assert.NilError(t, secrets.Unseal("abc"))
+	assert.DeepEqual(t, secrets, BuildSecrets{Token: "token", Env: in.Env})
+
+	secrets = BuildSecrets{Sealed: sealed, SealKey: key}
+	assert.Assert(t, secrets.Unseal("other") != nil)
+}
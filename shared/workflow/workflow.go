@@ -9,13 +9,16 @@ import (
 )
 
 type Input struct {
-	GitURL       string
-	ApiURL       string
-	ApiKey       string
-	BucketID     string
-	ProjectSlug  string
-	DeploymentID string
-	UserEnv      string
+	GitURL        string
+	ApiURL        string
+	ApiKey        string
+	BucketID      string
+	ProjectSlug   string
+	DeploymentID  string
+	Framework     string
+	BuildSettings string
+	Env           map[string]string
+	Token         string
 }
 
 type TriggerWorkflowConfig struct {
@@ -25,6 +28,7 @@ type TriggerWorkflowConfig struct {
 	WorkflowFile string
 	Ref          string
 	GithubToken  string
+	SecretsKey   string
 }
 
 type WorkflowClient struct {
@@ -42,23 +46,61 @@ func NewWorkflowClient(ctx context.Context, token string) *WorkflowClient {
 	}
 }
 
+type GitHubRunner struct {
+	client *WorkflowClient
+	cfg    TriggerWorkflowConfig
+}
+
+func NewGitHubRunner(ctx context.Context, cfg TriggerWorkflowConfig) *GitHubRunner {
+	if cfg.Owner == "" {
+		cfg.Owner = "chrollo-lucifer-12"
+	}
+	if cfg.Repo == "" {
+		cfg.Repo = "vercel"
+	}
+	if cfg.WorkflowFile == "" {
+		cfg.WorkflowFile = "build.yml"
+	}
+	if cfg.Ref == "" {
+		cfg.Ref = "main"
+	}
+
+	return &GitHubRunner{
+		client: NewWorkflowClient(ctx, cfg.GithubToken),
+		cfg:    cfg,
+	}
+}
+
+func (r *GitHubRunner) Run(ctx context.Context, in Input) error {
+	cfg := r.cfg
+	cfg.Inputs = in
+	return r.client.TriggerWorkflow(ctx, cfg)
+}
+
 func (w *WorkflowClient) TriggerWorkflow(
 	ctx context.Context,
 	cfg TriggerWorkflowConfig,
 ) error {
 
+	sealed, err := SealInputs(cfg.SecretsKey, cfg.Inputs)
+	if err != nil {
+		return err
+	}
+
 	event := github.CreateWorkflowDispatchEventRequest{
 		Ref: cfg.Ref,
 		Inputs: map[string]interface{}{
-			"gitURL":       cfg.Inputs.GitURL,
-			"bucketId":     cfg.Inputs.BucketID,
-			"projectSlug":  cfg.Inputs.ProjectSlug,
-			"deploymentId": cfg.Inputs.DeploymentID,
-			"userEnv":      "test",
+			"gitURL":        cfg.Inputs.GitURL,
+			"bucketId":      cfg.Inputs.BucketID,
+			"projectSlug":   cfg.Inputs.ProjectSlug,
+			"deploymentId":  cfg.Inputs.DeploymentID,
+			"framework":     cfg.Inputs.Framework,
+			"buildSettings": cfg.Inputs.BuildSettings,
+			"buildSecrets":  sealed,
 		},
 	}
 
-	_, err := w.client.Actions.CreateWorkflowDispatchEventByFileName(
+	_, err = w.client.Actions.CreateWorkflowDispatchEventByFileName(
 		ctx,
 		cfg.Owner,
 		cfg.Repo,
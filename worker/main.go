@@ -2,10 +2,15 @@ package main
 
 import (
 	"context"
+	"log"
 	"sync"
+	"time"
 
+	"github.com/chrollo-lucifer-12/shared/certs"
+	"github.com/chrollo-lucifer-12/shared/domains"
 	"github.com/chrollo-lucifer-12/shared/env"
 	"github.com/chrollo-lucifer-12/shared/queue"
+	"github.com/chrollo-lucifer-12/shared/workflow"
 )
 
 func main() {
@@ -13,11 +18,47 @@ func main() {
 	ctx := context.TODO()
 
 	emailWorker := queue.NewEmailWorkerServer(env.RedisUrl.GetValue(), env.ResendApiKey.GetValue())
-	workflowWorker := queue.NewWorkflowWorker(ctx, env.GithubToken.GetValue(), env.RedisUrl.GetValue())
+	runner, err := workflow.NewBuildRunner(ctx, workflow.RunnerConfig{
+		Kind: env.BuildRunner.GetValue(),
+		GitHub: workflow.TriggerWorkflowConfig{
+			GithubToken:  env.GithubToken.GetValue(),
+			Owner:        env.BuildGithubOwner.GetValue(),
+			Repo:         env.BuildGithubRepo.GetValue(),
+			WorkflowFile: env.BuildGithubWorkflow.GetValue(),
+			Ref:          env.BuildGithubRef.GetValue(),
+			SecretsKey:   env.BuildSecretsKey.GetValue(),
+		},
+		Local: workflow.LocalConfig{
+			Binary:     env.BuildServerBinary.GetValue(),
+			WorkDir:    env.BuildWorkDir.GetValue(),
+			SourceRoot: env.BuildSourceRoot.GetValue(),
+		},
+		Docker: workflow.DockerConfig{
+			Socket:  env.BuildDockerSocket.GetValue(),
+			Image:   env.BuildDockerImage.GetValue(),
+			Network: env.BuildDockerNetwork.GetValue(),
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	workflowWorker := queue.NewWorkflowWorker(ctx, runner, env.Dsn.GetValue(), env.RedisUrl.GetValue(), env.EnvEncryptionKey.GetValue())
 	analyticsWorker := queue.NewAnalyticsWorker(ctx, env.Dsn.GetValue(), env.RedisUrl.GetValue())
+	certificateWorker := queue.NewCertificateWorker(ctx, env.Dsn.GetValue(), env.RedisUrl.GetValue(), env.BaseDomain.GetValue(), certs.Config{
+		DirectoryURL: env.AcmeDirectoryURL.GetValue(),
+		CABundle:     env.AcmeCABundle.GetValue(),
+		Email:        env.AcmeEmail.GetValue(),
+		RenewBefore:  time.Duration(env.AcmeRenewBeforeHours.GetInt64()) * time.Hour,
+	})
+
+	domainWorker := queue.NewDomainWorker(ctx, env.Dsn.GetValue(), env.RedisUrl.GetValue(), domains.NewVerifier(
+		domains.NewResolver(env.DnsResolverAddr.GetValue()),
+		env.BaseDomain.GetValue(),
+	))
 
 	var wg sync.WaitGroup
-	wg.Add(3)
+	wg.Add(5)
 
 	go func() {
 		defer wg.Done()
@@ -34,5 +75,15 @@ func main() {
 		analyticsWorker.Start()
 	}()
 
+	go func() {
+		defer wg.Done()
+		certificateWorker.Start()
+	}()
+
+	go func() {
+		defer wg.Done()
+		domainWorker.Start()
+	}()
+
 	wg.Wait()
 }